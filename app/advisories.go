@@ -0,0 +1,200 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/protocols/hash"
+	"github.com/dappstore/go-dapp/protocols/publish"
+	"github.com/pkg/errors"
+)
+
+// VulnerabilitiesClaimPath is the claim path at which the result of the
+// EnforceAdvisories policy is recorded.
+const VulnerabilitiesClaimPath = "dapp.vulnerabilities"
+
+// The possible statuses of an advisory check
+const (
+	AdvisoriesClear        = "clear"
+	AdvisoriesAffected     = "affected"
+	AdvisoriesUnreachable  = "publisher-unreachable"
+	AdvisoriesUnverifiable = "unverifiable"
+	AdvisoriesSkipped      = "skipped"
+)
+
+// ErrRevoked is returned by the EnforceAdvisories policy when the running
+// binary has been revoked by its publisher, failing the app's creation.
+var ErrRevoked = errors.New("dapp: this version has been revoked by its publisher")
+
+// Vulnerabilities is the claim made by the EnforceAdvisories policy.
+// `Severity` is the most severe severity of the advisories that affect the
+// running binary.
+type Vulnerabilities struct {
+	Status     string
+	Publisher  string
+	Binary     string             `json:",omitempty"`
+	Severity   string             `json:",omitempty"`
+	Advisories []publish.Advisory `json:",omitempty"`
+	Error      string             `json:",omitempty"`
+}
+
+// EnforceAdvisories is a policy that checks the running binary against the
+// vulnerability and revocation advisories issued by `Publisher`, as recorded
+// in the publisher's kv or in their latest publication.  The running binary is
+// hashed using `Hasher` (falling back to the app's store if it can hash).
+//
+// A warning is written to stderr for every advisory that affects the running
+// binary.  A degraded advisory puts the app in degraded mode (see
+// App.Degraded), and a revoked binary fails the policy with ErrRevoked; the
+// outcome is still claimed, although the app's other policies are rolled
+// back.
+// Advisories are not checked in developer mode and during dry runs.
+type EnforceAdvisories struct {
+	Publisher string
+	Hasher    hash.Hasher
+}
+
+// PolicyProvides implements `DependentPolicy`
+func (p *EnforceAdvisories) PolicyProvides() []string { return nil }
+
+// PolicyRequires implements `DependentPolicy`
+func (p *EnforceAdvisories) PolicyRequires() []string { return providerDeps }
+
+// PolicyName implements `NamedPolicy`
+func (p *EnforceAdvisories) PolicyName() string { return "enforce-advisories" }
+
+// ApplyDappPolicy applies `p` to `app`
+func (p *EnforceAdvisories) ApplyDappPolicy(app *App) error {
+	var result Vulnerabilities
+	if dapp.DevMode() || app.DryRun() {
+		result = Vulnerabilities{Status: AdvisoriesSkipped, Publisher: p.Publisher}
+	} else if exe, err := os.Executable(); err != nil {
+		result = Vulnerabilities{
+			Status:    AdvisoriesUnverifiable,
+			Publisher: p.Publisher,
+			Error:     err.Error(),
+		}
+	} else {
+		result = p.check(app, exe)
+	}
+
+	// the outcome is recorded even when a revocation fails the app
+	err := app.keepClaim(VulnerabilitiesClaimPath, result)
+	if err != nil {
+		return errors.Wrap(err, "policy-enforce-advisories: failed to claim vulnerabilities")
+	}
+
+	switch result.Status {
+	case AdvisoriesUnreachable:
+		fmt.Fprintf(os.Stderr, "dapp: failed to check advisories: %s\n", result.Error)
+	case AdvisoriesUnverifiable:
+		fmt.Fprintf(os.Stderr, "dapp: failed to hash binary for advisories: %s\n", result.Error)
+	}
+
+	for _, advisory := range result.Advisories {
+		fmt.Fprintf(os.Stderr, "dapp: %s advisory %s: %s\n",
+			advisory.Severity, advisory.ID, advisory.Summary)
+	}
+
+	switch result.Severity {
+	case publish.SeverityDegraded:
+		app.degraded = true
+		app.OnRollback(func() { app.degraded = false })
+	case publish.SeverityRevoked:
+		return errors.Wrap(ErrRevoked, "policy-enforce-advisories")
+	}
+
+	return nil
+}
+
+// check checks the binary at `exe` against the advisories issued by the
+// publisher.  Failures to hash the binary are recorded as unverifiable, and
+// failures to load the advisories as unreachable.
+func (p *EnforceAdvisories) check(app *App, exe string) (result Vulnerabilities) {
+	result.Publisher = p.Publisher
+
+	hasher := p.Hasher
+	if hasher == nil {
+		hasher = app.storeHasher()
+	}
+	if hasher == nil {
+		result.Status = AdvisoriesUnverifiable
+		result.Error = "no hasher available"
+		return
+	}
+
+	binary, err := hashLocalPath(hasher, exe)
+	if err != nil {
+		result.Status = AdvisoriesUnverifiable
+		result.Error = err.Error()
+		return
+	}
+	result.Binary = binary.String()
+
+	advisories, err := p.advisories(app)
+	if err != nil {
+		result.Status = AdvisoriesUnreachable
+		result.Error = err.Error()
+		return
+	}
+
+	result.Status = AdvisoriesClear
+	for _, advisory := range advisories {
+		if !advisory.Affects(binary) {
+			continue
+		}
+
+		result.Status = AdvisoriesAffected
+		result.Advisories = append(result.Advisories, advisory)
+		if severity(advisory.Severity) > severity(result.Severity) {
+			result.Severity = advisory.Severity
+		}
+	}
+
+	return
+}
+
+// advisories loads the advisories issued by the publisher
+func (p *EnforceAdvisories) advisories(app *App) ([]publish.Advisory, error) {
+	publisher, err := app.Providers.ParseIdentity(p.Publisher)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse publisher")
+	}
+
+	pub := app.publish()
+
+	// a publisher need not have published anything to issue advisories
+	publication, err := pub.GetPublications(publisher)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve publication")
+	}
+
+	advisories, err := pub.GetAdvisories(publisher, publication)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load advisories")
+	}
+
+	return advisories, nil
+}
+
+// Degraded returns true if the running binary is affected by an advisory of
+// degraded severity.  Apps should disable any functionality that is not
+// essential while degraded.
+func (a *App) Degraded() bool {
+	return a.degraded
+}
+
+// severity ranks the severity `s`, treating unknown severities as warnings.
+func severity(s string) int {
+	switch s {
+	case "":
+		return 0
+	case publish.SeverityDegraded:
+		return 2
+	case publish.SeverityRevoked:
+		return 3
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/dappstore/go-dapp/protocols/dfs"
+	"github.com/dappstore/go-dapp/protocols/publish"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ DependentPolicy = &EnforceAdvisories{}
+
+func TestEnforceAdvisories_check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-advisories")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client := mem.New()
+	app := &App{ID: "advisories"}
+	app.Providers.IdentityProvider = client
+	app.Providers.KV = client
+	app.Providers.Store = client
+
+	publisher, err := client.RandomIdentity()
+	require.NoError(t, err)
+	p := &EnforceAdvisories{Publisher: publisher.PublicKey()}
+
+	exe := filepath.Join(dir, "exe")
+	require.NoError(t, ioutil.WriteFile(exe, []byte("v1"), 0755))
+	binary := client.HashLocalPath(exe).String()
+
+	// no advisories
+	result := p.check(app, exe)
+	assert.Equal(t, AdvisoriesClear, result.Status)
+	assert.Equal(t, binary, result.Binary)
+
+	// advisories recorded in the kv
+	raw, err := json.Marshal([]publish.Advisory{
+		{ID: "1", Severity: publish.SeverityWarning, Hashes: []string{binary}},
+		{ID: "2", Severity: publish.SeverityRevoked, Hashes: []string{"QmOther"}},
+	})
+	require.NoError(t, err)
+	advisories, err := dfs.New(client).StoreString(string(raw))
+	require.NoError(t, err)
+	_, err = client.Set(publisher, publish.AdvisoriesKey, advisories.Bytes())
+	require.NoError(t, err)
+
+	result = p.check(app, exe)
+	assert.Equal(t, AdvisoriesAffected, result.Status)
+	assert.Equal(t, publish.SeverityWarning, result.Severity)
+	if assert.Len(t, result.Advisories, 1) {
+		assert.Equal(t, "1", result.Advisories[0].ID)
+	}
+
+	// advisories included in the publication
+	pubdir := filepath.Join(dir, "publication")
+	require.NoError(t, os.MkdirAll(filepath.Join(pubdir, publish.AdvisoriesPath), 0755))
+	raw, err = json.Marshal(publish.Advisory{
+		ID:       "3",
+		Severity: publish.SeverityDegraded,
+		Hashes:   []string{binary},
+	})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(pubdir, publish.AdvisoriesPath, "3.json"), raw, 0644,
+	))
+	publication, err := client.StorePath(pubdir)
+	require.NoError(t, err)
+	_, err = client.Set(publisher, "dapp:publications", publication.Bytes())
+	require.NoError(t, err)
+
+	result = p.check(app, exe)
+	assert.Equal(t, AdvisoriesAffected, result.Status)
+	assert.Equal(t, publish.SeverityDegraded, result.Severity)
+	assert.Len(t, result.Advisories, 2)
+
+	// an unknown publisher cannot be checked
+	p = &EnforceAdvisories{Publisher: "nobody"}
+	result = p.check(app, exe)
+	assert.Equal(t, AdvisoriesUnreachable, result.Status)
+
+	// a failing hasher leaves the binary unverifiable, not the publisher
+	// unreachable
+	p = &EnforceAdvisories{Publisher: publisher.PublicKey(), Hasher: panicHasher{}}
+	result = p.check(app, exe)
+	assert.Equal(t, AdvisoriesUnverifiable, result.Status)
+	assert.Contains(t, result.Error, "hashing unavailable")
+
+	// as does having no hasher at all
+	app.Providers.Store = nil
+	p = &EnforceAdvisories{Publisher: publisher.PublicKey()}
+	result = p.check(app, exe)
+	assert.Equal(t, AdvisoriesUnverifiable, result.Status)
+}
+
+func TestEnforceAdvisories_revoked(t *testing.T) {
+	client := mem.New()
+	app := &App{ID: "advisories"}
+	app.Providers.IdentityProvider = client
+	app.Providers.KV = client
+	app.Providers.Store = client
+
+	publisher, err := client.RandomIdentity()
+	require.NoError(t, err)
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	raw, err := json.Marshal([]publish.Advisory{{
+		ID:       "1",
+		Severity: publish.SeverityRevoked,
+		Hashes:   []string{client.HashLocalPath(exe).String()},
+	}})
+	require.NoError(t, err)
+	advisories, err := dfs.New(client).StoreString(string(raw))
+	require.NoError(t, err)
+	_, err = client.Set(publisher, publish.AdvisoriesKey, advisories.Bytes())
+	require.NoError(t, err)
+
+	// a revoked binary fails the policy rather than exiting
+	p := &EnforceAdvisories{Publisher: publisher.PublicKey()}
+	err = p.ApplyDappPolicy(app)
+	assert.True(t, errors.Is(err, ErrRevoked))
+
+	// and fails the app, whose claims still record the revocation
+	app, err = NewApp("revoked",
+		&Store{Store: client},
+		&KV{KV: client},
+		&IdentityProvider{IdentityProvider: client},
+		p,
+	)
+	assert.True(t, errors.Is(err, ErrRevoked))
+	assert.Contains(t, app.Claims().CurrentClaims(), `"Severity":"revoked"`)
+}
@@ -1,72 +1,250 @@
 package app
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/metrics"
 	"github.com/dappstore/go-dapp/protocols/claim"
+	"github.com/dappstore/go-dapp/protocols/publish"
 	"github.com/pkg/errors"
 )
 
-// ApplyPolicy applies `p` t `a`
+// ApplyPolicy applies `p` t `a`.  If `p` fails, any providers it set and
+// claims it made are rolled back.
 func (a *App) ApplyPolicy(p Policy) error {
-	if p == nil {
-		return errors.New("policy is nil")
-	}
+	return a.ApplyPolicies(p)
+}
 
-	err := p.ApplyDappPolicy(a)
-	if err != nil {
-		return errors.Wrap(err, "failed applying policy")
-	}
+// ApplyPolicies applies all `policies` onto `a`.  If any policy fails, the
+// providers set and claims made by every policy in the batch are rolled back.
+func (a *App) ApplyPolicies(policies ...Policy) error {
+	return a.transaction(func() error {
+		for _, p := range policies {
+			err := a.applyPolicy(p)
+			if err != nil {
+				return errors.Wrap(err, "dapp: failed-policy")
+			}
+		}
 
-	a.policies = append(a.policies, p)
-	return nil
+		return nil
+	})
 }
 
-// ApplyPolicies applies all `policies` onto `a`.
-func (a *App) ApplyPolicies(policies ...Policy) error {
-	for _, p := range policies {
-		err := a.ApplyPolicy(p)
-		if err != nil {
-			return errors.Wrap(err, "dapp: failed-policy")
+// Claims returns the claim protocol on which the policies applied to `a` make
+// their claims.  Every app makes its claims on its own protocol, such that
+// apps in the same process never share claims.
+func (a *App) Claims() *claim.Protocol {
+	a.claimsOnce.Do(func() {
+		if a.claims == nil {
+			a.claims = claim.New()
 		}
-	}
+	})
 
-	return nil
+	return a.claims
 }
 
-// CurrentUser returns the current user's identity
+// CurrentUser returns the current user's identity.  When the app persists
+// sessions, the first call restores the session saved by a previous run.
 func (a *App) CurrentUser() dapp.Identity {
+	a.sessionOnce.Do(a.restoreSession)
 	return dapp.CurrentUser(a.ID)
 }
 
-// Login logs `user` into `a`
+// UserFromContext returns the user attached to `ctx` with dapp.WithUser,
+// falling back to the current user when `ctx` carries no user.
+func (a *App) UserFromContext(ctx context.Context) dapp.Identity {
+	user := dapp.UserFromContext(ctx)
+	if user != nil {
+		return user
+	}
+
+	return a.CurrentUser()
+}
+
+// Login logs `user` into `a`, saving the session when the app persists
+// sessions.  A session that cannot be saved is reported on stderr; use
+// LoginAndSave to handle the failure instead.
 func (a *App) Login(user dapp.Identity) {
+	err := a.LoginAndSave(user)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dapp: failed to save session: %s\n", err)
+	}
+}
+
+// LoginAndSave logs `user` into `a`, returning an error if the app persists
+// sessions and the session cannot be saved.
+func (a *App) LoginAndSave(user dapp.Identity) error {
 	dapp.Login(a.ID, user)
+
+	if a.sessions == nil {
+		return nil
+	}
+
+	err := a.sessions.Save(a.ID, user)
+	if err != nil {
+		return errors.Wrap(err, "dapp: failed to save session")
+	}
+
+	return nil
+}
+
+// Logout logs the current user out of `a`, wiping any saved session.
+func (a *App) Logout() error {
+	dapp.Logout(a.ID)
+
+	if a.sessions == nil {
+		return nil
+	}
+
+	err := a.sessions.Delete(a.ID)
+	if err != nil {
+		return errors.Wrap(err, "dapp: failed to delete session")
+	}
+
+	return nil
+}
+
+// SendPayment sends a simple payment of `amount` to `dest` from the user
+// attached to `ctx` with dapp.WithUser, falling back to the current user.
+//
+// NOTE: this is not intended to be the final api... it's just a prototype
+func (a *App) SendPayment(
+	ctx context.Context,
+	dest dapp.Identity,
+	amount string,
+) (dapp.TX, error) {
+	payments, err := a.payments()
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "dapp: send payment failed")
+	}
+
+	user := a.UserFromContext(ctx)
+	if user == nil {
+		return dapp.TX(""), errors.New("dapp: cannot send payment without a logged in user")
+	}
+
+	tx, err := payments.SendPayment(ctx, user, dest, amount)
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "dapp: send payment failed")
+	}
+
+	return tx, nil
 }
 
-// SendPayment sends a simple payment.
+// WaitForPayment waits for a payment to be made to the app's identity,
+// returning the transaction in which the payment was made.  Waiting stops
+// when `ctx` is canceled or, if `timeout` is non-zero, when `timeout` has
+// elapsed.
 //
 // NOTE: this is not intended to be the final api... it's just a prototype
-func (a *App) SendPayment(dest dapp.Identity, amount string) (dapp.TX, error) {
-	// watch for payments to developer address.  When one is seen, return
+func (a *App) WaitForPayment(
+	ctx context.Context,
+	timeout time.Duration,
+) (dapp.TX, error) {
+	payments, err := a.payments()
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "dapp: wait for payment failed")
+	}
+
+	id, err := a.Providers.ParseIdentity(a.ID)
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "dapp: failed to parse app id")
+	}
+
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	tx, err := payments.WaitForPayment(ctx, id)
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "dapp: wait for payment failed")
+	}
 
-	return dapp.TX(""), errors.New("not implemented")
+	return tx, nil
 }
 
-// WaitForPayment waits for a developer to publish
+// WatchPayments is a policy that calls `fn` with each payment made to the
+// app's identity while the app runs (see App.Run).  Watching stops when the
+// app shuts down, and a failing `fn` stops the app.  Each wait starts from the
+// most recent payment, so payments made while `fn` runs may be missed.
 //
 // NOTE: this is not intended to be the final api... it's just a prototype
-func (a *App) WaitForPayment() (dapp.TX, error) {
-	// watch for payments to developer address.  When one is seen, return
+func WatchPayments(fn func(ctx context.Context, tx dapp.TX) error) Policy {
+	return WithDependencies(&fnPolicy{
+		"watch-payments",
+		func(app *App) error {
+			_, err := app.payments()
+			if err != nil {
+				return errors.Wrap(err, "watch-payments: cannot watch payments")
+			}
+
+			app.Go(func(ctx context.Context) error {
+				for {
+					tx, err := app.WaitForPayment(ctx, 0)
+					if ctx.Err() != nil {
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+
+					err = fn(ctx, tx)
+					if err != nil {
+						return errors.Wrap(err, "watch-payments: payment handler failed")
+					}
+				}
+			})
+
+			return nil
+		},
+	}, []string{DepIdentityProvider}, nil)
+}
+
+// applyPolicy applies `p` to `a` outside of any transaction
+func (a *App) applyPolicy(p Policy) error {
+	if p == nil {
+		return errors.New("policy is nil")
+	}
 
-	return dapp.TX(""), errors.New("not implemented")
+	err := p.ApplyDappPolicy(a)
+	if err != nil {
+		return errors.Wrap(err, "failed applying policy")
+	}
+
+	a.policies = append(a.policies, p)
+	return nil
 }
 
+// init applies `policies` to a new app, leaving no trace in the app or its
+// claims should it fail.
 func (a *App) init(policies []Policy) error {
-	err := a.ApplyPolicies(policies...)
+	return a.transaction(func() error {
+		return a.setup(policies)
+	})
+}
+
+func (a *App) setup(policies []Policy) error {
+	policies, err := sortPolicies(policies)
+	if err != nil {
+		return errors.Wrap(err, "dapp: create-app failed to order policies")
+	}
+
+	err = a.ApplyPolicies(append(policies, ClaimVersion)...)
 	if err != nil {
 		return errors.Wrap(err, "dapp: create-app failed to apply policies")
 	}
 
+	if a.dryRun {
+		a.Explain(os.Stdout)
+		return ErrExplained
+	}
+
 	if a.Providers.IdentityProvider == nil {
 		return errors.New("dapp: no identity provider initialized while applying policies")
 	}
@@ -79,21 +257,75 @@ func (a *App) init(policies []Policy) error {
 		return errors.New("dapp: no store initialized while applying policies")
 	}
 
-	err = claim.LockClaimers()
+	err = a.Claims().LockClaimers()
 	if err != nil {
 		return errors.Wrap(err, "dapp: failed to lock claimers")
 	}
 
-	// TODO: extract these to policies
-	// if *printID {
-	// 	fmt.Println(id)
-	// 	os.Exit(0)
-	// }
-	//
-	// if *printVersion {
-	// 	fmt.Println(version)
-	// 	os.Exit(0)
-	// }
+	// claims are written to disk when the app shuts down, to the default path
+	// unless a claims-file policy chose another.
+	if !a.claimsFile {
+		claimsFile, err := defaultClaimsFile(a.ID)
+		if err != nil {
+			return errors.Wrap(err, "dapp: failed to find claims file")
+		}
+
+		err = a.applyPolicy(claimsFile)
+		if err != nil {
+			return errors.Wrap(err, "dapp: failed to apply claims file")
+		}
+	}
+
+	// a binary started to probe a self-update has started successfully once its
+	// policies have been applied.
+	if os.Getenv(UpdateProbeEnv) != "" {
+		return ErrUpdateProbed
+	}
+
+	// a one-shot self-update is complete once its policies have been applied;
+	// the updated binary takes over from the next run.
+	if a.exitAfterUpdate {
+		err = a.Shutdown()
+		if err != nil {
+			return errors.Wrap(err, "dapp: failed to shut down after update")
+		}
+
+		return ErrUpdated
+	}
 
 	return nil
 }
+
+// payments returns the app's identity provider as a payment provider, erroring
+// if the provider cannot make payments.
+func (a *App) payments() (PaymentProvider, error) {
+	payments, ok := metrics.Unwrap(a.Providers.IdentityProvider).(PaymentProvider)
+	if !ok {
+		return nil, errors.New("identity provider cannot make payments")
+	}
+
+	return payments, nil
+}
+
+// publish returns the publish protocol for the app's providers, merging the
+// app's claims into publications.
+func (a *App) publish() *publish.Protocol {
+	return publish.NewWithClaims(a.Providers.KV, a.Providers.Store, a.Claims())
+}
+
+// restoreSession logs in the user saved in the app's session store, if any.
+func (a *App) restoreSession() {
+	if a.sessions == nil || dapp.CurrentUser(a.ID) != nil {
+		return
+	}
+
+	user, err := a.sessions.Load(a.ID, a.Providers.IdentityProvider)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dapp: failed to restore session: %s\n", err)
+		return
+	}
+
+	if user != nil {
+		dapp.Login(a.ID, user)
+	}
+}
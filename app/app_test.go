@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/dappstore/go-dapp/protocols/claim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_Claims(t *testing.T) {
+	before := claim.CurrentClaims()
+
+	newApp := func(name string) *App {
+		client := mem.New()
+		app, err := NewApp("claims",
+			&Store{Store: client},
+			&KV{KV: client},
+			&IdentityProvider{IdentityProvider: client},
+			Name(name),
+		)
+		require.NoError(t, err)
+		return app
+	}
+
+	// apps in the same process make their claims independently
+	first := newApp("first")
+	second := newApp("second")
+	assert.NotEqual(t, first.Claims(), second.Claims())
+	assert.Contains(t, first.Claims().CurrentClaims(), `"first"`)
+	assert.Contains(t, second.Claims().CurrentClaims(), `"second"`)
+	assert.NotContains(t, second.Claims().CurrentClaims(), `"first"`)
+
+	// every app claims its version
+	assert.Contains(t, first.Claims().CurrentClaims(), `"version"`)
+
+	// the default claim protocol is untouched
+	assert.Equal(t, before, claim.CurrentClaims())
+
+	// apps created without NewApp have claims too
+	app := &App{ID: "literal"}
+	require.NoError(t, app.ApplyPolicy(Name("literal")))
+	assert.Contains(t, app.Claims().CurrentClaims(), `"literal"`)
+}
+
+// paymentsProvider is an identity provider that records the payments sent,
+// and receives the payments sent on `received`
+type paymentsProvider struct {
+	*mem.Client
+	payers   []dapp.Identity
+	received chan dapp.TX
+}
+
+func (p *paymentsProvider) SendPayment(
+	ctx context.Context,
+	from dapp.Identity,
+	to dapp.Identity,
+	amount string,
+) (dapp.TX, error) {
+	p.payers = append(p.payers, from)
+	return dapp.TX("tx"), nil
+}
+
+func (p *paymentsProvider) WaitForPayment(
+	ctx context.Context,
+	to dapp.Identity,
+) (dapp.TX, error) {
+	select {
+	case tx := <-p.received:
+		return tx, nil
+	case <-ctx.Done():
+		return dapp.TX(""), ctx.Err()
+	}
+}
+
+func TestApp_SendPayment(t *testing.T) {
+	payments := &paymentsProvider{Client: mem.New()}
+	app := &App{ID: "payments"}
+	app.Providers.IdentityProvider = payments
+
+	alice := &dapp.MockIdentity{PK: "alice"}
+	bob := &dapp.MockIdentity{PK: "bob"}
+	dest := &dapp.MockIdentity{PK: "dest"}
+
+	// no user to pay from
+	_, err := app.SendPayment(context.Background(), dest, "1.0")
+	assert.Error(t, err)
+
+	// payments are made by the user attached to the context, falling back to
+	// the current user
+	app.Login(alice)
+	defer app.Logout()
+
+	_, err = app.SendPayment(dapp.WithUser(context.Background(), bob), dest, "1.0")
+	require.NoError(t, err)
+	_, err = app.SendPayment(context.Background(), dest, "1.0")
+	require.NoError(t, err)
+
+	require.Len(t, payments.payers, 2)
+	assert.True(t, bob.Equals(payments.payers[0]))
+	assert.True(t, alice.Equals(payments.payers[1]))
+}
+
+func TestWatchPayments(t *testing.T) {
+	payments := &paymentsProvider{
+		Client:   mem.New(),
+		received: make(chan dapp.TX),
+	}
+	id, err := payments.RandomIdentity()
+	require.NoError(t, err)
+
+	app := &App{ID: id.PublicKey()}
+	app.Providers.IdentityProvider = payments
+
+	seen := make(chan dapp.TX)
+	require.NoError(t, app.ApplyPolicy(WatchPayments(
+		func(ctx context.Context, tx dapp.TX) error {
+			seen <- tx
+			return nil
+		},
+	)))
+
+	// payments are watched while the app runs, and watching stops when it
+	// shuts down
+	err = app.Run(context.Background(), func(ctx context.Context) error {
+		payments.received <- dapp.TX("first")
+		assert.Equal(t, dapp.TX("first"), <-seen)
+		payments.received <- dapp.TX("second")
+		assert.Equal(t, dapp.TX("second"), <-seen)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// an identity provider that cannot make payments cannot be watched
+	app = &App{ID: id.PublicKey()}
+	app.Providers.IdentityProvider = payments.Client
+	assert.Error(t, app.ApplyPolicy(WatchPayments(
+		func(ctx context.Context, tx dapp.TX) error { return nil },
+	)))
+}
+
+func TestApp_sessions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-sessions")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client := mem.New()
+	newApp := func() *App {
+		app := &App{ID: "sessions"}
+		app.Providers.IdentityProvider = client
+		require.NoError(t, app.ApplyPolicy(&Sessions{Dir: dir, Passphrase: "secret"}))
+		return app
+	}
+
+	user, err := client.RandomIdentity()
+	require.NoError(t, err)
+
+	app := newApp()
+	require.NoError(t, app.LoginAndSave(user))
+
+	// a new app, as in a later run, restores the saved session
+	dapp.Logout(app.ID)
+	restored := newApp().CurrentUser()
+	require.NotNil(t, restored)
+	assert.True(t, user.Equals(restored))
+
+	// logging out wipes the saved session
+	require.NoError(t, newApp().Logout())
+	assert.Nil(t, newApp().CurrentUser())
+}
@@ -0,0 +1,291 @@
+package app
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/local"
+	"github.com/dappstore/go-dapp/protocols/publish"
+	"github.com/pkg/errors"
+)
+
+// Config is the declarative form of an app's policies, as loaded from a
+// `dapp.toml` or `dapp.json` file.
+type Config struct {
+	ID          string `json:"id" toml:"id"`
+	Name        string `json:"name" toml:"name"`
+	Description string `json:"description" toml:"description"`
+	Developer   string `json:"developer" toml:"developer"`
+
+	Providers struct {
+		Store    ProviderConfig `json:"store" toml:"store"`
+		KV       ProviderConfig `json:"kv" toml:"kv"`
+		Identity ProviderConfig `json:"identity" toml:"identity"`
+	} `json:"providers" toml:"providers"`
+
+	Trust struct {
+		Roots []string `json:"roots" toml:"roots"`
+	} `json:"trust" toml:"trust"`
+
+	Update struct {
+		Publisher  string `json:"publisher" toml:"publisher"`
+		Channel    string `json:"channel" toml:"channel"`
+		Auto       bool   `json:"auto" toml:"auto"`
+		Verify     bool   `json:"verify" toml:"verify"`
+		Strict     bool   `json:"strict" toml:"strict"`
+		Advisories bool   `json:"advisories" toml:"advisories"`
+		Notify     bool   `json:"notify" toml:"notify"`
+	} `json:"update" toml:"update"`
+
+	Health struct {
+		Check   bool   `json:"check" toml:"check"`
+		Strict  bool   `json:"strict" toml:"strict"`
+		Timeout string `json:"timeout" toml:"timeout"`
+	} `json:"health" toml:"health"`
+}
+
+// ProviderConfig selects the implementation of a provider.  `Type` is the
+// scheme of a provider registered with dapp.RegisterProvider, such as "ipfs",
+// "stellar", "local" or "mem", and `Endpoint` is the ipfs api address, horizon
+// url or local state directory respectively.  `Network` selects the stellar
+// network, "public", "test" or the passphrase of a custom network, which also
+// requires an endpoint.  Alternatively, `URI` selects a provider by its full
+// uri.  An empty config selects the default provider.  The "mem" provider is
+// only registered once the program imports the mem package.
+type ProviderConfig struct {
+	Type     string `json:"type" toml:"type"`
+	Endpoint string `json:"endpoint" toml:"endpoint"`
+	Network  string `json:"network" toml:"network"`
+	URI      string `json:"uri" toml:"uri"`
+}
+
+// LoadConfig loads the config file at `path`, decoding it as json when it has
+// a `.json` extension and as toml otherwise.
+func LoadConfig(path string) (*Config, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "dapp: failed to read config")
+	}
+
+	var config Config
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(raw, &config)
+	} else {
+		err = toml.Unmarshal(raw, &config)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "dapp: failed to decode config")
+	}
+
+	return &config, nil
+}
+
+// NewAppFromConfig creates a new app using the id and policies declared in
+// the config file at `path`, in addition to `policies`.
+func NewAppFromConfig(path string, policies ...Policy) (*App, error) {
+	config, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.ID == "" {
+		return nil, errors.New("dapp: config does not declare an app id")
+	}
+
+	configured, err := config.Policies()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewApp(config.ID, append(configured, policies...)...)
+}
+
+// Policies returns the policies declared by `c`.
+func (c *Config) Policies() ([]Policy, error) {
+	var policies []Policy
+
+	providers, err := c.providerPolicies()
+	if err != nil {
+		return nil, err
+	}
+	policies = append(policies, providers...)
+
+	if c.Name != "" {
+		policies = append(policies, Name(c.Name))
+	}
+
+	if c.Description != "" {
+		policies = append(policies, Description(c.Description))
+	}
+
+	if c.Developer != "" {
+		policies = append(policies, Developer(c.Developer))
+	}
+
+	if len(c.Trust.Roots) > 0 {
+		policies = append(policies, TrustRoots(c.Trust.Roots...))
+	}
+
+	health := c.Health
+	if health.Check {
+		checks := &HealthChecks{Strict: health.Strict}
+		if health.Timeout != "" {
+			checks.Timeout, err = time.ParseDuration(health.Timeout)
+			if err != nil {
+				return nil, errors.Wrap(err, "dapp: invalid health check timeout")
+			}
+		}
+
+		policies = append(policies, checks)
+	}
+
+	update := c.Update
+	if update.Publisher != "" {
+		selfUpdate := &SelfUpdate{Publisher: update.Publisher}
+		if update.Auto {
+			selfUpdate.Auto = true
+			selfUpdate.Channel = update.Channel
+			if selfUpdate.Channel == "" {
+				selfUpdate.Channel = publish.DefaultChannel
+			}
+		}
+
+		policies = append(policies, selfUpdate)
+
+		if update.Verify {
+			policies = append(policies, &VerifySelf{
+				Publisher: update.Publisher,
+				Channel:   update.Channel,
+				Strict:    update.Strict,
+			})
+		}
+
+		if update.Notify {
+			policies = append(policies, &NotifyUpdates{
+				Publisher: update.Publisher,
+				Channel:   update.Channel,
+			})
+		}
+
+		if update.Advisories {
+			policies = append(policies, &EnforceAdvisories{
+				Publisher: update.Publisher,
+			})
+		}
+	}
+
+	return policies, nil
+}
+
+func (c *Config) providerPolicies() ([]Policy, error) {
+	pc := c.Providers
+	if pc.Store == (ProviderConfig{}) &&
+		pc.KV == (ProviderConfig{}) &&
+		pc.Identity == (ProviderConfig{}) {
+		return []Policy{DefaultProviders}, nil
+	}
+
+	clients := map[ProviderConfig]interface{}{}
+
+	store, err := c.provider(clients, pc.Store, "ipfs")
+	if err != nil {
+		return nil, errors.Wrap(err, "dapp: invalid store config")
+	}
+
+	kv, err := c.provider(clients, pc.KV, "stellar")
+	if err != nil {
+		return nil, errors.Wrap(err, "dapp: invalid kv config")
+	}
+
+	ids, err := c.provider(clients, pc.Identity, "stellar")
+	if err != nil {
+		return nil, errors.Wrap(err, "dapp: invalid identity config")
+	}
+
+	sp, ok := store.(dapp.Store)
+	if !ok {
+		return nil, errors.New("dapp: configured store provider cannot store content")
+	}
+
+	kp, ok := kv.(dapp.KV)
+	if !ok {
+		return nil, errors.New("dapp: configured kv provider is not a kv")
+	}
+
+	ip, ok := ids.(dapp.IdentityProvider)
+	if !ok {
+		return nil, errors.New("dapp: configured identity provider cannot provide identities")
+	}
+
+	return []Policy{NewPolicy("configured-providers",
+		&Store{Store: sp},
+		&KV{KV: kp},
+		&IdentityProvider{IdentityProvider: ip},
+	)}, nil
+}
+
+// provider returns the provider selected by `pc`, using `fallback` as the
+// type when none is specified.  Providers are shared through `clients` when
+// the same type and endpoint are selected more than once.
+func (c *Config) provider(
+	clients map[ProviderConfig]interface{},
+	pc ProviderConfig,
+	fallback string,
+) (interface{}, error) {
+	if pc.Type == "" && pc.URI == "" {
+		pc.Type = fallback
+	}
+
+	client, ok := clients[pc]
+	if ok {
+		return client, nil
+	}
+
+	client, err := newProvider(c.ID, pc)
+	if err != nil {
+		return nil, err
+	}
+
+	clients[pc] = client
+	return client, nil
+}
+
+// newProvider opens the provider selected by `pc` through the provider
+// registry.  A config without a uri selects the provider registered under its
+// type, with its endpoint forming the rest of the uri: an endpoint that is
+// itself a url, such as "http://localhost:8000", selects the provider
+// registered as "<type>+<scheme>".
+func newProvider(id string, pc ProviderConfig) (interface{}, error) {
+	if pc.URI != "" {
+		return dapp.OpenProvider(pc.URI)
+	}
+
+	endpoint := pc.Endpoint
+
+	// local state defaults to a directory of the app's own
+	if pc.Type == "local" && endpoint == "" {
+		var err error
+		endpoint, err = local.DefaultDir(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	uri := pc.Type + "://" + endpoint
+	if strings.Contains(endpoint, "://") {
+		uri = pc.Type + "+" + endpoint
+	}
+
+	if pc.Network != "" {
+		uri += "?" + url.Values{"network": {pc.Network}}.Encode()
+	}
+
+	return dapp.OpenProvider(uri)
+}
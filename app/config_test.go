@@ -0,0 +1,279 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/local"
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/dappstore/go-dapp/protocols/publish"
+	"github.com/dappstore/go-dapp/stellar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name string, contents string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+		return path
+	}
+
+	names := func(policies []Policy) []string {
+		ret := make([]string, len(policies))
+		for i, p := range policies {
+			ret[i] = describePolicy(p).Name
+		}
+		return ret
+	}
+
+	// toml
+	config, err := LoadConfig(write("dapp.toml", `
+id = "GDGIXJPUTJIYHHJ2TYWO2HJMFNT7M767ZB33SFGTD77JUE3YZ6YZBUD4"
+name = "coinop"
+developer = "GA6AJ6WPO6BDFUKUJKPDW3SILWSXLP62O72JTY3JDUJVR2EMIOBMJDLM"
+
+[providers.store]
+type = "mem"
+
+[providers.kv]
+type = "mem"
+
+[providers.identity]
+uri = "stellar+testnet://horizon-testnet.stellar.org"
+
+[trust]
+roots = ["GA6AJ6WPO6BDFUKUJKPDW3SILWSXLP62O72JTY3JDUJVR2EMIOBMJDLM"]
+
+[health]
+check = true
+timeout = "2s"
+
+[update]
+publisher = "GA6AJ6WPO6BDFUKUJKPDW3SILWSXLP62O72JTY3JDUJVR2EMIOBMJDLM"
+channel = "beta"
+verify = true
+advisories = true
+notify = true
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "coinop", config.Name)
+	assert.Equal(t, "mem", config.Providers.KV.Type)
+
+	policies, err := config.Policies()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"configured-providers",
+		"set-name",
+		"set-developer",
+		"set-trust-roots",
+		"health-checks",
+		"self-update",
+		"verify-self",
+		"notify-updates",
+		"enforce-advisories",
+	}, names(policies))
+
+	// mem providers with the same endpoint are shared, the identity provider
+	// is selected by uri
+	providers := policies[0].(*compositePolicy).policies
+	store := providers[0].(*Store).Store
+	assert.IsType(t, &mem.Client{}, store)
+	assert.Equal(t, store, providers[1].(*KV).KV)
+	assert.NotEqual(t, store, providers[2].(*IdentityProvider).IdentityProvider)
+
+	assert.Equal(t, 2*time.Second, policies[4].(*HealthChecks).Timeout)
+
+	// updates only happen automatically when requested
+	assert.Equal(t, "", policies[5].(*SelfUpdate).Channel)
+	assert.Equal(t, "beta", policies[6].(*VerifySelf).Channel)
+
+	// json, with default providers
+	config, err = LoadConfig(write("dapp.json", `{"id": "app", "description": "json"}`))
+	require.NoError(t, err)
+	policies, err = config.Policies()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default-providers", "set-description"}, names(policies))
+
+	// stellar providers select their network
+	config, err = LoadConfig(write("pubnet.toml", `
+[providers.kv]
+type = "stellar"
+network = "public"
+`))
+	require.NoError(t, err)
+	policies, err = config.Policies()
+	require.NoError(t, err)
+	kv := policies[0].(*compositePolicy).policies[1].(*KV).KV
+	assert.Equal(t, stellar.PublicNetwork, kv.(*stellar.Client).NetworkPassphrase())
+	assert.Equal(t, stellar.PublicHorizonURL, kv.(*stellar.Client).URL)
+
+	// unknown providers fail
+	config, err = LoadConfig(write("bad.toml", `
+[providers.store]
+type = "floppy"
+`))
+	require.NoError(t, err)
+	_, err = config.Policies()
+	assert.Error(t, err)
+
+	// missing files fail
+	_, err = LoadConfig(filepath.Join(dir, "missing.toml"))
+	assert.Error(t, err)
+}
+
+func TestConfig_autoUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-config-update")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	state := filepath.Join(dir, "state")
+	client, err := local.NewDir(state)
+	require.NoError(t, err)
+	publisher, err := client.RandomIdentity()
+	require.NoError(t, err)
+
+	// updates replace a fixture rather than the test binary
+	exe := filepath.Join(dir, "exe")
+	require.NoError(t, ioutil.WriteFile(exe, []byte("#!/bin/sh\nexit 0\n"), 0755))
+	defer func(fn func() (string, error)) { executable = fn }(executable)
+	executable = func() (string, error) { return exe, nil }
+
+	pubdir := filepath.Join(dir, "publication")
+	require.NoError(t, os.MkdirAll(filepath.Join(pubdir, publish.ChannelsPath), 0755))
+	release := func(binary dapp.Hash) {
+		manifest, err := json.Marshal(map[string]string{publish.Platform(): binary.String()})
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(
+			filepath.Join(pubdir, publish.ChannelsPath, publish.DefaultChannel), manifest, 0644,
+		))
+		publication, err := client.StorePath(pubdir)
+		require.NoError(t, err)
+		_, err = client.Set(publisher, "dapp:publications", publication.Bytes())
+		require.NoError(t, err)
+	}
+
+	// the fixture is the latest release
+	release(client.HashLocalPath(exe))
+
+	path := filepath.Join(dir, "dapp.toml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(fmt.Sprintf(`
+id = "auto-update"
+
+[providers.store]
+type = "local"
+endpoint = %q
+
+[providers.kv]
+type = "local"
+endpoint = %q
+
+[providers.identity]
+type = "local"
+endpoint = %q
+
+[update]
+publisher = %q
+auto = true
+`, state, state, state, publisher.PublicKey())), 0644))
+
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	policies, err := config.Policies()
+	require.NoError(t, err)
+
+	// auto updates default to the stable channel
+	update := policies[1].(*SelfUpdate)
+	assert.True(t, update.Auto)
+	assert.Equal(t, publish.DefaultChannel, update.Channel)
+
+	// an up to date app keeps running
+	app, err := NewApp(config.ID, policies...)
+	require.NoError(t, err)
+	assert.Equal(t, "auto-update", app.ID)
+	require.NoError(t, app.Shutdown())
+
+	// as does one that is updated in place
+	next := filepath.Join(dir, "next")
+	require.NoError(t, ioutil.WriteFile(next, []byte("#!/bin/sh\nexit 0\n# v2\n"), 0755))
+	published, err := client.StorePath(next)
+	require.NoError(t, err)
+	release(published)
+
+	app, err = NewApp(config.ID, policies...)
+	require.NoError(t, err)
+	assert.Equal(t, published, client.HashLocalPath(exe))
+	require.NoError(t, app.Shutdown())
+
+	// and one whose update fails
+	require.NoError(t, os.Remove(filepath.Join(pubdir, publish.ChannelsPath, publish.DefaultChannel)))
+	publication, err := client.StorePath(pubdir)
+	require.NoError(t, err)
+	_, err = client.Set(publisher, "dapp:publications", publication.Bytes())
+	require.NoError(t, err)
+
+	_, err = NewApp(config.ID, policies...)
+	require.NoError(t, err)
+
+	// auto updates need a channel
+	app = &App{ID: "auto-update"}
+	err = app.ApplyPolicy(&SelfUpdate{Publisher: publisher.PublicKey(), Auto: true})
+	assert.Error(t, err)
+}
+
+func TestNewProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-providers")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	open := func(pc ProviderConfig) interface{} {
+		provider, err := newProvider("providers", pc)
+		require.NoError(t, err)
+		return provider
+	}
+
+	// types are looked up in the provider registry
+	assert.IsType(t, &mem.Client{}, open(ProviderConfig{Type: "mem"}))
+	assert.IsType(t, &local.Client{}, open(ProviderConfig{Type: "local", Endpoint: dir}))
+
+	// endpoints that are urls select the matching provider
+	c := open(ProviderConfig{Type: "stellar", Endpoint: "http://localhost:8000"}).(*stellar.Client)
+	assert.Equal(t, "http://localhost:8000", c.URL)
+	c = open(ProviderConfig{Type: "stellar", Endpoint: "https://horizon.example.com"}).(*stellar.Client)
+	assert.Equal(t, "https://horizon.example.com", c.URL)
+	assert.Equal(t, stellar.TestNetwork, c.NetworkPassphrase())
+
+	c = open(ProviderConfig{
+		Type:     "stellar",
+		Endpoint: "http://localhost:8000",
+		Network:  "Standalone Network ; February 2017",
+	}).(*stellar.Client)
+	assert.Equal(t, "Standalone Network ; February 2017", c.NetworkPassphrase())
+
+	// known networks default to their own horizon
+	c = open(ProviderConfig{Type: "stellar", Network: "public"}).(*stellar.Client)
+	assert.Equal(t, stellar.PublicHorizonURL, c.URL)
+	assert.Equal(t, stellar.PublicNetwork, c.NetworkPassphrase())
+
+	// custom networks have no default horizon, so they need an endpoint rather
+	// than signing for one network and talking to another
+	_, err = newProvider("providers", ProviderConfig{
+		Type:    "stellar",
+		Network: "Standalone Network ; February 2017",
+	})
+	assert.Error(t, err)
+
+	// unknown types fail
+	_, err = newProvider("providers", ProviderConfig{Type: "floppy"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The dependencies that represent the core providers of an app.  Policies can
+// also depend upon other policies by name.
+const (
+	DepIdentityProvider = "provider:identity"
+	DepKV               = "provider:kv"
+	DepStore            = "provider:store"
+)
+
+// DependentPolicy represents a policy that declares the dependencies it
+// requires to be applied before it, and the dependencies it provides to
+// others.  A named policy always provides its own name.
+type DependentPolicy interface {
+	Policy
+	PolicyRequires() []string
+	PolicyProvides() []string
+}
+
+// WithDependencies decorates `p` with the dependencies it `requires` and
+// `provides`.
+func WithDependencies(p Policy, requires []string, provides []string) Policy {
+	return &dependentPolicy{Policy: p, requires: requires, provides: provides}
+}
+
+// dependentPolicy decorates a policy with declared dependencies
+type dependentPolicy struct {
+	Policy
+	requires []string
+	provides []string
+}
+
+// PolicyName implements `NamedPolicy`
+func (p *dependentPolicy) PolicyName() string {
+	return describePolicy(p.Policy).Name
+}
+
+// PolicyRequires implements `DependentPolicy`
+func (p *dependentPolicy) PolicyRequires() []string { return p.requires }
+
+// PolicyProvides implements `DependentPolicy`
+func (p *dependentPolicy) PolicyProvides() []string { return p.provides }
+
+// PolicyRequires implements `DependentPolicy`, requiring everything the
+// composite's children require that is not provided by a sibling.
+func (p *compositePolicy) PolicyRequires() []string {
+	provided := map[string]bool{}
+	for _, child := range p.policies {
+		for _, dep := range policyProvides(child) {
+			provided[dep] = true
+		}
+	}
+
+	var ret []string
+	for _, child := range p.policies {
+		for _, dep := range policyRequires(child) {
+			if !provided[dep] {
+				ret = append(ret, dep)
+			}
+		}
+	}
+
+	return ret
+}
+
+// PolicyProvides implements `DependentPolicy`, providing everything the
+// composite's children provide.
+func (p *compositePolicy) PolicyProvides() []string {
+	var ret []string
+	for _, child := range p.policies {
+		ret = append(ret, policyProvides(child)...)
+	}
+
+	return ret
+}
+
+// sortPolicies orders `policies` such that every policy is preceded by the
+// policies that provide its requirements.  Otherwise, the given order is
+// preserved.
+func sortPolicies(policies []Policy) ([]Policy, error) {
+	available := map[string]bool{}
+	for _, p := range policies {
+		for _, dep := range policyProvides(p) {
+			available[dep] = true
+		}
+	}
+
+	for _, p := range policies {
+		for _, dep := range policyRequires(p) {
+			if !available[dep] {
+				return nil, errors.Errorf(
+					"dapp: policy %s requires %s, which no policy provides",
+					describePolicy(p).Name, dep,
+				)
+			}
+		}
+	}
+
+	provided := map[string]bool{}
+	remaining := append([]Policy(nil), policies...)
+	sorted := make([]Policy, 0, len(policies))
+
+	for len(remaining) > 0 {
+		next := -1
+		for i, p := range remaining {
+			if satisfied(p, provided) {
+				next = i
+				break
+			}
+		}
+
+		if next == -1 {
+			names := make([]string, len(remaining))
+			for i, p := range remaining {
+				names[i] = describePolicy(p).Name
+			}
+
+			return nil, errors.Errorf(
+				"dapp: dependency cycle between policies: %s",
+				strings.Join(names, ", "),
+			)
+		}
+
+		p := remaining[next]
+		remaining = append(remaining[:next], remaining[next+1:]...)
+		sorted = append(sorted, p)
+
+		for _, dep := range policyProvides(p) {
+			provided[dep] = true
+		}
+	}
+
+	return sorted, nil
+}
+
+func satisfied(p Policy, provided map[string]bool) bool {
+	for _, dep := range policyRequires(p) {
+		if !provided[dep] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func policyRequires(p Policy) []string {
+	dp, ok := p.(DependentPolicy)
+	if !ok {
+		return nil
+	}
+
+	return dp.PolicyRequires()
+}
+
+func policyProvides(p Policy) []string {
+	var ret []string
+
+	named, ok := p.(NamedPolicy)
+	if ok {
+		ret = append(ret, named.PolicyName())
+	}
+
+	dp, ok := p.(DependentPolicy)
+	if ok {
+		ret = append(ret, dp.PolicyProvides()...)
+	}
+
+	return ret
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortPolicies(t *testing.T) {
+	noop := func(name string, requires ...string) Policy {
+		return WithDependencies(
+			&fnPolicy{name, func(*App) error { return nil }},
+			requires, nil,
+		)
+	}
+
+	names := func(policies []Policy) []string {
+		ret := make([]string, len(policies))
+		for i, p := range policies {
+			ret[i] = describePolicy(p).Name
+		}
+		return ret
+	}
+
+	// providers are moved before the policies that need them
+	sorted, err := sortPolicies([]Policy{
+		Name("sorted"),
+		Developer("GA6AJ6WPO6BDFUKUJKPDW3SILWSXLP62O72JTY3JDUJVR2EMIOBMJDLM"),
+		DefaultProviders,
+		&VerifySelf{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t,
+		[]string{"set-name", "default-providers", "set-developer", "verify-self"},
+		names(sorted),
+	)
+
+	// composite policies provide what their children provide
+	sorted, err = sortPolicies([]Policy{
+		noop("a", DepKV, "c"),
+		NewPolicy("b", &KV{}),
+		noop("c"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "a"}, names(sorted))
+
+	// missing requirements fail
+	_, err = sortPolicies([]Policy{noop("a", DepStore)})
+	assert.Contains(t, err.Error(), "requires provider:store")
+
+	// cycles fail
+	_, err = sortPolicies([]Policy{noop("a", "b"), noop("b", "a"), noop("c")})
+	assert.Contains(t, err.Error(), "cycle between policies: a, b")
+}
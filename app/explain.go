@@ -0,0 +1,89 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dappstore/go-dapp/metrics"
+	"github.com/dappstore/go-dapp/protocols/claim"
+)
+
+// DryRun returns true if policies are being applied to `a` only to describe
+// the app.  Policies that would touch the network skip doing so during a dry
+// run.
+func (a *App) DryRun() bool {
+	return a.dryRun
+}
+
+// Explain writes a description of the policies applied to `a`, the providers
+// they selected and the claims they made to `w`.
+func (a *App) Explain(w io.Writer) {
+	fmt.Fprintf(w, "app: %s\n", a.ID)
+
+	fmt.Fprintln(w, "policies:")
+	for _, info := range a.Policies() {
+		explainPolicy(w, info, 1)
+	}
+
+	fmt.Fprintln(w, "providers:")
+	fmt.Fprintf(w, "  identity: %s\n", describeProvider(a.Providers.IdentityProvider))
+	fmt.Fprintf(w, "  kv: %s\n", describeProvider(a.Providers.KV))
+	fmt.Fprintf(w, "  store: %s\n", describeProvider(a.Providers.Store))
+
+	fmt.Fprintln(w, "claims:")
+	fmt.Fprintf(w, "  %s\n", a.Claims().CurrentClaims())
+}
+
+// Policies returns a description of the policies applied to `a`, in the order
+// they were applied.
+func (a *App) Policies() []PolicyInfo {
+	ret := make([]PolicyInfo, len(a.policies))
+	for i, p := range a.policies {
+		ret[i] = describePolicy(p)
+	}
+
+	return ret
+}
+
+func describePolicy(p Policy) PolicyInfo {
+	var info PolicyInfo
+
+	named, ok := p.(NamedPolicy)
+	if ok {
+		info.Name = named.PolicyName()
+	} else {
+		info.Name = fmt.Sprintf("%T", p)
+	}
+
+	composite, ok := p.(*compositePolicy)
+	if ok {
+		for _, child := range composite.policies {
+			info.Children = append(info.Children, describePolicy(child))
+		}
+	}
+
+	return info
+}
+
+// describeProvider describes `provider`, or the provider it decorates
+func describeProvider(provider interface{}) string {
+	provider = metrics.Unwrap(provider)
+	if provider == nil {
+		return "<none>"
+	}
+
+	claimer, ok := provider.(claim.MakesClaims)
+	if !ok {
+		return fmt.Sprintf("%T", provider)
+	}
+
+	return fmt.Sprintf("%s (%T)", claimer.ClaimerName(), provider)
+}
+
+func explainPolicy(w io.Writer, info PolicyInfo, depth int) {
+	fmt.Fprintf(w, "%s%s\n", strings.Repeat("  ", depth), info.Name)
+	for _, child := range info.Children {
+		explainPolicy(w, child, depth+1)
+	}
+}
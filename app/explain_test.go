@@ -0,0 +1,55 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_Policies(t *testing.T) {
+	client := mem.New()
+	app := &App{ID: "explained"}
+
+	noop := &fnPolicy{"noop", func(*App) error { return nil }}
+	require.NoError(t, app.ApplyPolicies(
+		NewPolicy("mem-providers",
+			&Store{Store: client},
+			&KV{KV: client},
+			&IdentityProvider{IdentityProvider: client},
+		),
+		noop,
+	))
+
+	assert.Equal(t, []PolicyInfo{
+		{Name: "mem-providers", Children: []PolicyInfo{
+			{Name: "store"},
+			{Name: "kv"},
+			{Name: "identity-provider"},
+		}},
+		{Name: "noop"},
+	}, app.Policies())
+
+	var out bytes.Buffer
+	app.Explain(&out)
+	assert.Contains(t, out.String(), "app: explained\n")
+	assert.Contains(t, out.String(), "\n  mem-providers\n    store\n")
+	assert.Contains(t, out.String(), "  kv: mem (*mem.Client)\n")
+}
+
+func TestApp_explainDryRun(t *testing.T) {
+	client := mem.New()
+	app := &App{ID: "dry-run", dryRun: true}
+
+	// a dry run reports that it explained the app rather than exiting
+	err := app.init([]Policy{
+		&Store{Store: client},
+		&KV{KV: client},
+		&IdentityProvider{IdentityProvider: client},
+	})
+	assert.True(t, errors.Is(err, ErrExplained))
+	assert.True(t, ShouldExit(err))
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/metrics"
+	"github.com/pkg/errors"
+)
+
+// HealthClaimPath is the claim path at which the result of the HealthChecks
+// policy is recorded.
+const HealthClaimPath = "dapp.health"
+
+// DefaultHealthCheckTimeout is the deadline for the checks of the
+// HealthChecks policy when it does not specify one.
+var DefaultHealthCheckTimeout = 5 * time.Second
+
+// The possible statuses of a provider's health
+const (
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+	HealthUnchecked = "unchecked"
+	HealthSkipped   = "skipped"
+)
+
+// ProviderHealth is the health of a single core provider, as claimed by the
+// HealthChecks policy.  Providers that do not implement dapp.HealthChecker are
+// unchecked.
+type ProviderHealth struct {
+	Provider string
+	Status   string
+	Latency  string `json:",omitempty"`
+	Error    string `json:",omitempty"`
+}
+
+// HealthChecks is a policy that checks the health of the app's core providers
+// that implement dapp.HealthChecker, claiming the status and latency of each
+// under `dapp.health`.  The checks run in parallel and must complete within
+// `Timeout` (DefaultHealthCheckTimeout if zero).  When `Strict` is true, an
+// unhealthy provider fails the policy; otherwise a warning is written to
+// stderr.  No checks are made during dry runs.
+type HealthChecks struct {
+	Timeout time.Duration
+	Strict  bool
+}
+
+// PolicyProvides implements `DependentPolicy`
+func (p *HealthChecks) PolicyProvides() []string { return nil }
+
+// PolicyRequires implements `DependentPolicy`
+func (p *HealthChecks) PolicyRequires() []string { return providerDeps }
+
+// PolicyName implements `NamedPolicy`
+func (p *HealthChecks) PolicyName() string { return "health-checks" }
+
+// ApplyDappPolicy applies `p` to `app`
+func (p *HealthChecks) ApplyDappPolicy(app *App) error {
+	results := p.check(app)
+
+	err := app.Claims().Make(HealthClaimPath, results)
+	if err != nil {
+		return errors.Wrap(err, "policy-health-checks: failed to claim health")
+	}
+
+	var unhealthy []string
+	for role, result := range results {
+		if result.Status == HealthUnhealthy {
+			unhealthy = append(unhealthy, role)
+		}
+	}
+	sort.Strings(unhealthy)
+
+	if len(unhealthy) == 0 {
+		return nil
+	}
+
+	if p.Strict {
+		return errors.Errorf(
+			"policy-health-checks: unhealthy providers: %s",
+			strings.Join(unhealthy, ", "),
+		)
+	}
+
+	for _, role := range unhealthy {
+		fmt.Fprintf(os.Stderr, "dapp: %s provider is unhealthy: %s\n",
+			role, results[role].Error)
+	}
+
+	return nil
+}
+
+// check checks the health of the app's providers, keyed by their role.
+func (p *HealthChecks) check(app *App) map[string]ProviderHealth {
+	providers := map[string]interface{}{
+		"identity": app.Providers.IdentityProvider,
+		"kv":       app.Providers.KV,
+		"store":    app.Providers.Store,
+	}
+
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var (
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+		results = map[string]ProviderHealth{}
+	)
+
+	for role, provider := range providers {
+		result := ProviderHealth{Provider: describeProvider(provider)}
+
+		checker, ok := metrics.Unwrap(provider).(dapp.HealthChecker)
+		switch {
+		case app.DryRun():
+			result.Status = HealthSkipped
+		case !ok:
+			result.Status = HealthUnchecked
+		}
+
+		if result.Status != "" {
+			lock.Lock()
+			results[role] = result
+			lock.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(role string, result ProviderHealth) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := checkHealth(ctx, checker)
+			result.Latency = time.Since(start).String()
+
+			if err != nil {
+				result.Status = HealthUnhealthy
+				result.Error = err.Error()
+			} else {
+				result.Status = HealthHealthy
+			}
+
+			lock.Lock()
+			results[role] = result
+			lock.Unlock()
+		}(role, result)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// checkHealth runs `checker`, failing when `ctx` is done even if the checker
+// does not respect it.
+func checkHealth(ctx context.Context, checker dapp.HealthChecker) error {
+	done := make(chan error, 1)
+	go func() { done <- checker.CheckHealth(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "health check timed out")
+	}
+}
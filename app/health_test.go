@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ DependentPolicy = &HealthChecks{}
+
+// checkedStore is a store whose health is determined by `check`
+type checkedStore struct {
+	*mem.Client
+	check func(ctx context.Context) error
+}
+
+func (s *checkedStore) CheckHealth(ctx context.Context) error {
+	return s.check(ctx)
+}
+
+func TestHealthChecks(t *testing.T) {
+	newApp := func(check func(ctx context.Context) error) *App {
+		client := mem.New()
+		app := &App{ID: "health"}
+		app.Providers.IdentityProvider = client
+		app.Providers.KV = client
+		app.Providers.Store = &checkedStore{client, check}
+		return app
+	}
+
+	// healthy providers are claimed, others are unchecked
+	app := newApp(func(ctx context.Context) error { return nil })
+	results := (&HealthChecks{}).check(app)
+	assert.Equal(t, HealthHealthy, results["store"].Status)
+	assert.NotEmpty(t, results["store"].Latency)
+	assert.Equal(t, HealthUnchecked, results["kv"].Status)
+	assert.Equal(t, HealthUnchecked, results["identity"].Status)
+
+	require.NoError(t, app.ApplyPolicy(&HealthChecks{Strict: true}))
+	assert.Contains(t, app.Claims().CurrentClaims(), `"healthy"`)
+
+	// unhealthy providers only fail strict checks
+	failing := func(ctx context.Context) error { return errors.New("down") }
+	app = newApp(failing)
+	assert.Error(t, app.ApplyPolicy(&HealthChecks{Strict: true}))
+	assert.Equal(t, "{}", app.Claims().CurrentClaims())
+
+	app = newApp(failing)
+	require.NoError(t, app.ApplyPolicy(&HealthChecks{}))
+	assert.Contains(t, app.Claims().CurrentClaims(), `"unhealthy"`)
+
+	// checks that outlast the timeout are unhealthy, even if they ignore it
+	block := make(chan struct{})
+	defer close(block)
+	app = newApp(func(ctx context.Context) error { <-block; return nil })
+	results = (&HealthChecks{Timeout: 10 * time.Millisecond}).check(app)
+	assert.Equal(t, HealthUnhealthy, results["store"].Status)
+	assert.Contains(t, results["store"].Error, "timed out")
+}
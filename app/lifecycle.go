@@ -0,0 +1,207 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// ShutdownTimeout is the amount of time shutdown hooks have to complete once
+// an app stops running.
+var ShutdownTimeout = 10 * time.Second
+
+// Task represents work run by an app, stopping when its context is canceled.
+type Task func(ctx context.Context) error
+
+// lifecycle tracks the background tasks and shutdown hooks of an app
+type lifecycle struct {
+	lock     sync.Mutex
+	tasks    []Task
+	hooks    []Task
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	taskErr  error
+	shutdown sync.Once
+}
+
+// ClaimsFile is a policy that writes the claims made by the app to `path` when
+// the app shuts down.  Apps created with NewApp write their claims to the path
+// provided with the `-dapp.claims` flag, or to DefaultClaimsPath, unless this
+// policy is applied.
+func ClaimsFile(path string) Policy {
+	return &fnPolicy{
+		"claims-file",
+		func(app *App) error {
+			app.claimsFile = true
+			app.OnShutdown(func(ctx context.Context) error {
+				fs := afero.NewOsFs()
+				err := fs.MkdirAll(filepath.Dir(path), 0700)
+				if err != nil {
+					return errors.Wrap(err, "claims-file: failed to create claims dir")
+				}
+
+				err = app.Claims().WriteFile(fs, path, 0600)
+				if err != nil {
+					return errors.Wrap(err, "claims-file: failed to write claims")
+				}
+
+				return nil
+			})
+
+			return nil
+		},
+	}
+}
+
+// DefaultClaimsPath returns the path in the user's cache to which the app
+// identified by `id` writes its claims.
+func DefaultClaimsPath(id string) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "claims-file: failed to find cache dir")
+	}
+
+	return filepath.Join(cache, "dapp", "claims", id+".json"), nil
+}
+
+// defaultClaimsFile returns the claims-file policy applied by NewApp
+func defaultClaimsFile(id string) (Policy, error) {
+	path := dapp.ClaimsPath()
+	if path == "" {
+		var err error
+		path, err = DefaultClaimsPath(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ClaimsFile(path), nil
+}
+
+// Go registers `task` to run in the background while the app is running.
+// Tasks registered before Run is called are started by Run, and tasks
+// registered while the app is running are started immediately.  A task that
+// fails stops the app.
+func (a *App) Go(task Task) {
+	l := &a.lifecycle
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.tasks = append(l.tasks, task)
+	if l.ctx != nil {
+		a.start(task)
+	}
+}
+
+// OnShutdown registers `hook` to run when the app shuts down.  Hooks run in
+// the reverse order they were registered.
+func (a *App) OnShutdown(hook Task) {
+	l := &a.lifecycle
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.hooks = append(l.hooks, hook)
+}
+
+// Run runs `fn` along with the app's background tasks, then shuts the app
+// down.  The context passed to `fn` and to the tasks is canceled when `ctx` is
+// canceled, the process receives SIGINT or SIGTERM, or a task fails.  Run
+// returns the first error from `fn`, a task or a shutdown hook.
+func (a *App) Run(ctx context.Context, fn Task) error {
+	l := &a.lifecycle
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	l.lock.Lock()
+	if l.ctx != nil {
+		l.lock.Unlock()
+		return errors.New("dapp: app is already running")
+	}
+
+	l.ctx, l.cancel = context.WithCancel(ctx)
+	for _, task := range l.tasks {
+		a.start(task)
+	}
+	runCtx := l.ctx
+	l.lock.Unlock()
+
+	err := fn(runCtx)
+
+	l.cancel()
+	l.wg.Wait()
+
+	if err == nil {
+		err = l.taskErr
+	}
+
+	serr := a.Shutdown()
+	if err == nil {
+		err = serr
+	}
+
+	return err
+}
+
+// Shutdown stops the app's background tasks and runs its shutdown hooks in
+// reverse order, returning the first error a hook returns.  Hooks only run
+// once, no matter how many times Shutdown is called.
+func (a *App) Shutdown() (err error) {
+	l := &a.lifecycle
+
+	l.lock.Lock()
+	if l.cancel != nil {
+		l.cancel()
+	}
+	hooks := append([]Task(nil), l.hooks...)
+	l.lock.Unlock()
+
+	l.wg.Wait()
+
+	l.shutdown.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+
+		for i := len(hooks) - 1; i >= 0; i-- {
+			herr := hooks[i](ctx)
+			if herr != nil && err == nil {
+				err = errors.Wrap(herr, "dapp: shutdown hook failed")
+			}
+		}
+	})
+
+	return
+}
+
+// start runs `task` in the background, canceling the app's context should it
+// fail.  The lifecycle lock must be held.
+func (a *App) start(task Task) {
+	l := &a.lifecycle
+	l.wg.Add(1)
+
+	go func() {
+		defer l.wg.Done()
+
+		err := task(l.ctx)
+		if err == nil || l.ctx.Err() != nil {
+			return
+		}
+
+		l.lock.Lock()
+		if l.taskErr == nil {
+			l.taskErr = errors.Wrap(err, "dapp: background task failed")
+		}
+		l.lock.Unlock()
+
+		l.cancel()
+	}()
+}
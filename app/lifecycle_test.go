@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain keeps the claims and update checks of the apps under test out of
+// the user's cache.
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "dapp-cache")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("XDG_CACHE_HOME", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestApp_Run(t *testing.T) {
+	app := &App{ID: "lifecycle"}
+
+	var events []string
+	record := func(event string) Task {
+		return func(ctx context.Context) error {
+			events = append(events, event)
+			return nil
+		}
+	}
+
+	stopped := make(chan struct{})
+	app.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	})
+	app.OnShutdown(record("first-hook"))
+	app.OnShutdown(record("second-hook"))
+
+	err := app.Run(context.Background(), func(ctx context.Context) error {
+		events = append(events, "run")
+		return nil
+	})
+	require.NoError(t, err)
+
+	// background tasks are stopped before hooks run in reverse order
+	<-stopped
+	assert.Equal(t, []string{"run", "second-hook", "first-hook"}, events)
+
+	// hooks only run once
+	require.NoError(t, app.Shutdown())
+	assert.Len(t, events, 3)
+
+	// apps only run once
+	assert.Error(t, app.Run(context.Background(), record("again")))
+}
+
+func TestApp_Run_TaskFailure(t *testing.T) {
+	app := &App{ID: "lifecycle"}
+
+	app.Go(func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+
+	err := app.Run(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "boom")
+	}
+}
+
+func TestClaimsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-lifecycle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "claims.json")
+	app := &App{ID: "lifecycle"}
+	require.NoError(t, app.ApplyPolicy(ClaimsFile(path)))
+
+	err = app.Run(context.Background(), func(ctx context.Context) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestNewApp_claimsFile(t *testing.T) {
+	client := mem.New()
+	app, err := NewApp("lifecycle-claims",
+		&Store{Store: client},
+		&KV{KV: client},
+		&IdentityProvider{IdentityProvider: client},
+		Name("claims"),
+	)
+	require.NoError(t, err)
+
+	// apps write their claims to the default path unless told otherwise
+	path, err := DefaultClaimsPath(app.ID)
+	require.NoError(t, err)
+	require.NoError(t, app.Shutdown())
+
+	raw, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"claims"`)
+}
@@ -1,8 +1,11 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/protocols/claim"
+	"github.com/dappstore/go-dapp/session"
 	"github.com/pkg/errors"
 	"sync"
 )
@@ -19,6 +22,22 @@ type App struct {
 
 	once     sync.Once
 	policies []Policy
+	dryRun   bool
+	degraded bool
+	undo     []func()
+	kept     []keptClaim
+	depth    int
+
+	exitAfterUpdate bool
+
+	lifecycle lifecycle
+
+	claims     *claim.Protocol
+	claimsOnce sync.Once
+	claimsFile bool
+
+	sessions    *session.Store
+	sessionOnce sync.Once
 }
 
 // Policy values represent a policy that can change state on the app
@@ -26,10 +45,41 @@ type Policy interface {
 	ApplyDappPolicy(*App) error
 }
 
+// NamedPolicy represents a policy with a human friendly name, used when
+// describing the policies applied to an app.
+type NamedPolicy interface {
+	Policy
+	PolicyName() string
+}
+
+// PolicyInfo describes a policy applied to an app, along with the policies it
+// is composed of.
+type PolicyInfo struct {
+	Name     string
+	Children []PolicyInfo `json:",omitempty"`
+}
+
+// PaymentProvider represents an identity provider that can send payments
+// between identities and watch for incoming payments.
+type PaymentProvider interface {
+	SendPayment(ctx context.Context, from dapp.Identity, to dapp.Identity, amount string) (dapp.TX, error)
+	WaitForPayment(ctx context.Context, to dapp.Identity) (dapp.TX, error)
+}
+
+// ErrExplained is returned by NewApp when the `-dapp.explain` flag is
+// provided, once the app has been described.  Use ShouldExit to match it.
+var ErrExplained = errors.New("dapp: app explained")
+
 // NewApp creates a new dapp application with identity `id` and applies
-// `policies`.
+// `policies`.  Every app claims the version of its binary (see ClaimVersion).
+// When the `-dapp.explain` flag is provided, policies are applied as a dry
+// run, the resulting app is described on stdout and ErrExplained is returned.
 func NewApp(id string, policies ...Policy) (app *App, err error) {
-	app = &App{ID: id}
+	app = &App{
+		ID:     id,
+		dryRun: dapp.ExplainRequested(),
+		claims: claim.New(),
+	}
 	app.once.Do(func() {
 		err = app.init(policies)
 	})
@@ -37,6 +87,22 @@ func NewApp(id string, policies ...Policy) (app *App, err error) {
 	return
 }
 
+// ShouldExit returns true if `err`, returned by NewApp, reports that the app
+// has done all that its process was started to do, such as explaining the
+// app, printing its version or completing a one-shot update.  The program
+// should then exit successfully:
+//
+//	a, err := app.NewApp(id, policies...)
+//	if app.ShouldExit(err) {
+//		os.Exit(0)
+//	}
+func ShouldExit(err error) bool {
+	return errors.Is(err, ErrExplained) ||
+		errors.Is(err, ErrPrinted) ||
+		errors.Is(err, ErrUpdateProbed) ||
+		errors.Is(err, ErrUpdated)
+}
+
 // NewPolicy creates a new composite policy.
 func NewPolicy(name string, policies ...Policy) Policy {
 	return &compositePolicy{name, policies}
@@ -47,6 +113,9 @@ type compositePolicy struct {
 	policies []Policy
 }
 
+// PolicyName implements `NamedPolicy`
+func (p *compositePolicy) PolicyName() string { return p.name }
+
 // ApplyDappPolicy implements `Policy`
 func (p *compositePolicy) ApplyDappPolicy(app *App) error {
 	for i, cp := range p.policies {
@@ -66,6 +135,9 @@ type fnPolicy struct {
 	fn   func(app *App) error
 }
 
+// PolicyName implements `NamedPolicy`
+func (p *fnPolicy) PolicyName() string { return p.name }
+
 // ApplyDappPolicy implements `Policy`
 func (p *fnPolicy) ApplyDappPolicy(app *App) error {
 	err := p.fn(app)
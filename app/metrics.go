@@ -0,0 +1,53 @@
+package app
+
+import (
+	"github.com/dappstore/go-dapp/metrics"
+	"github.com/dappstore/go-dapp/protocols/hash"
+	"github.com/pkg/errors"
+)
+
+// Instrument is a policy that instruments the app's core providers, reporting
+// the latency and outcome of their operations to `Sink` (metrics.Default if
+// nil).  Serve metrics.Default (or any metrics.Registry) over http to expose
+// the metrics to prometheus.
+type Instrument struct {
+	Sink metrics.Sink
+}
+
+// PolicyProvides implements `DependentPolicy`
+func (p *Instrument) PolicyProvides() []string { return nil }
+
+// PolicyRequires implements `DependentPolicy`
+func (p *Instrument) PolicyRequires() []string { return providerDeps }
+
+// PolicyName implements `NamedPolicy`
+func (p *Instrument) PolicyName() string { return "instrument" }
+
+// ApplyDappPolicy implements `Policy`
+func (p *Instrument) ApplyDappPolicy(app *App) error {
+	sink := p.Sink
+	if sink == nil {
+		sink = metrics.Default
+	}
+
+	providers := &app.Providers
+	if providers.IdentityProvider == nil || providers.KV == nil || providers.Store == nil {
+		return errors.New("policy-instrument: cannot instrument missing providers")
+	}
+
+	if _, ok := providers.Store.(*metrics.Store); ok {
+		return errors.New("policy-instrument: providers already instrumented")
+	}
+
+	providers.IdentityProvider = metrics.NewIdentityProvider(providers.IdentityProvider, sink)
+	providers.KV = metrics.NewKV(providers.KV, sink)
+	providers.Store = metrics.NewStore(providers.Store, sink)
+	return nil
+}
+
+// storeHasher returns the app's store as a hash.Hasher, seeing through the
+// instrumentation applied by Instrument, or nil if the store cannot hash.
+func (a *App) storeHasher() hash.Hasher {
+	hasher, _ := metrics.Unwrap(a.Providers.Store).(hash.Hasher)
+	return hasher
+}
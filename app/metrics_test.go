@@ -0,0 +1,44 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/dappstore/go-dapp/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrument(t *testing.T) {
+	client := mem.New()
+	registry := metrics.NewRegistry()
+
+	app, err := NewApp("instrument",
+		&Instrument{Sink: registry},
+		&Store{Store: client},
+		&KV{KV: client},
+		&IdentityProvider{IdentityProvider: client},
+	)
+	require.NoError(t, err)
+
+	// the instrumented providers are applied after the providers they wrap
+	assert.IsType(t, &metrics.Store{}, app.Providers.Store)
+	assert.Equal(t, client, metrics.Unwrap(app.Providers.KV))
+	assert.Implements(t, (*dapp.KVDeleter)(nil), app.Providers.KV)
+	assert.IsType(t, &metrics.IdentityProvider{}, app.Providers.IdentityProvider)
+
+	// policies that hash find the instrumented store's hasher
+	assert.Equal(t, client, app.storeHasher())
+
+	_, err = app.Providers.RandomIdentity()
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, registry.WritePrometheus(&out))
+	assert.Contains(t, out.String(), `{provider="identity",op="RandomIdentity"} 1`)
+
+	// providers are only instrumented once
+	assert.Error(t, app.ApplyPolicy(&Instrument{Sink: registry}))
+}
@@ -0,0 +1,346 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/protocols/hash"
+	"github.com/dappstore/go-dapp/protocols/publish"
+	"github.com/pkg/errors"
+)
+
+// DefaultUpdateCheckInterval is the interval between checks for a new version
+// when the NotifyUpdates policy does not specify one.
+var DefaultUpdateCheckInterval = 24 * time.Hour
+
+// NotifyUpdates is a policy that prints a notice on stderr when `Publisher`
+// has published a newer version of the app on `Channel`
+// (publish.DefaultChannel if empty) than the one running.  The running binary
+// is hashed using `Hasher` (falling back to the app's store if it can hash).
+//
+// Publications carry no version, so their order is judged from what the
+// machine has seen: a release is newer than the running binary if the running
+// binary was itself seen as the channel's latest release, and older if it is
+// a binary that ran before the running one.  No notice is printed for an older
+// release, and a release whose order is unknown is reported as different.
+//
+// Checks are made in the background while the app runs (see App.Run), once
+// every `Interval` (DefaultUpdateCheckInterval if zero), until the app shuts
+// down.  Their results are cached in `CacheDir` (a directory in the user's
+// cache if empty), such that the publisher is consulted at most once per
+// interval across runs.  The running binary is only hashed when it has
+// changed since the last check.  No check is made in developer mode, during
+// dry runs or when the `-dapp.quiet` flag is provided.
+type NotifyUpdates struct {
+	Publisher string
+	Channel   string
+	Interval  time.Duration
+	CacheDir  string
+	Hasher    hash.Hasher
+}
+
+// updateCheck is the cached result of a check for a new version.  `Path`,
+// `Size` and `ModTime` identify the file that hashed to `Binary`, `Released`
+// records whether `Binary` has been seen as the latest release, and
+// `Previous` holds the binaries that ran before it.
+type updateCheck struct {
+	CheckedAt   time.Time
+	Channel     string
+	Path        string
+	Size        int64
+	ModTime     time.Time
+	Binary      string
+	Publication string
+	Latest      string
+	Released    bool
+	Previous    []string `json:",omitempty"`
+}
+
+// Newer returns true if the latest release is known to be newer than the
+// running binary
+func (c *updateCheck) Newer() bool {
+	return c.differs() && c.Released && !c.Older()
+}
+
+// Older returns true if the latest release is a binary that ran before the
+// running one
+func (c *updateCheck) Older() bool {
+	if !c.differs() {
+		return false
+	}
+
+	for _, previous := range c.Previous {
+		if previous == c.Latest {
+			return true
+		}
+	}
+
+	return false
+}
+
+// differs returns true if the latest release is not the running binary
+func (c *updateCheck) differs() bool {
+	return c.Latest != "" && c.Latest != c.Binary
+}
+
+// notice returns the notice to print for the check, or the empty string if
+// the running binary is up to date or newer than the latest release.
+func (c *updateCheck) notice() string {
+	switch {
+	case c.Newer():
+		return fmt.Sprintf(
+			"dapp: a new version is available on the %s channel, "+
+				"run with -dapp.update=%s to update",
+			c.Channel, c.Channel)
+	case c.differs() && !c.Older():
+		return fmt.Sprintf(
+			"dapp: a different version is published on the %s channel, "+
+				"run with -dapp.update=%s to switch to it",
+			c.Channel, c.Channel)
+	default:
+		return ""
+	}
+}
+
+// matches returns true if the check was made of the unchanged file `info` at
+// `path`
+func (c *updateCheck) matches(path string, info os.FileInfo) bool {
+	return c.Path == path &&
+		c.Size == info.Size() &&
+		c.ModTime.Equal(info.ModTime())
+}
+
+// PolicyProvides implements `DependentPolicy`
+func (p *NotifyUpdates) PolicyProvides() []string { return nil }
+
+// PolicyRequires implements `DependentPolicy`
+func (p *NotifyUpdates) PolicyRequires() []string { return providerDeps }
+
+// PolicyName implements `NamedPolicy`
+func (p *NotifyUpdates) PolicyName() string { return "notify-updates" }
+
+// ApplyDappPolicy applies `p` to `app`
+func (p *NotifyUpdates) ApplyDappPolicy(app *App) error {
+	if dapp.DevMode() || dapp.Quiet() || app.DryRun() {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+
+	app.Go(p.watch(app, exe))
+	return nil
+}
+
+// watch returns a task that checks for a new version of the binary at `exe`
+// once per check interval, printing a notice the first time each newer
+// release is seen.
+func (p *NotifyUpdates) watch(app *App, exe string) Task {
+	return func(ctx context.Context) error {
+		var noticed string
+
+		for {
+			// failing to check for a new version is not worth stopping the app
+			// for
+			check, err := p.check(app, exe, time.Now())
+			if err == nil && check.Latest != noticed {
+				if notice := check.notice(); notice != "" {
+					fmt.Fprintln(os.Stderr, notice)
+					noticed = check.Latest
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(p.interval()):
+			}
+		}
+	}
+}
+
+// interval returns the interval between checks
+func (p *NotifyUpdates) interval() time.Duration {
+	if p.Interval == 0 {
+		return DefaultUpdateCheckInterval
+	}
+
+	return p.Interval
+}
+
+// check checks whether a newer version of the binary at `exe` has been
+// published, using the cached result of a previous check if it was made
+// less than the check interval before `now` of the same, unchanged, file.
+// The binary is only hashed when it has changed since the cached check.
+func (p *NotifyUpdates) check(
+	app *App,
+	exe string,
+	now time.Time,
+) (*updateCheck, error) {
+	channel := p.Channel
+	if channel == "" {
+		channel = publish.DefaultChannel
+	}
+
+	info, err := os.Stat(exe)
+	if err != nil {
+		return nil, errors.Wrap(err, "notify-updates: failed to stat running binary")
+	}
+
+	path, err := p.cachePath(app, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &updateCheck{
+		CheckedAt: now,
+		Channel:   channel,
+		Path:      exe,
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+	}
+
+	cached, err := loadUpdateCheck(path)
+	if err != nil {
+		cached = nil
+	}
+
+	switch {
+	case cached != nil && cached.matches(exe, info):
+		if now.Sub(cached.CheckedAt) < p.interval() {
+			return cached, nil
+		}
+
+		check.Binary = cached.Binary
+	default:
+		hasher := p.Hasher
+		if hasher == nil {
+			hasher = app.storeHasher()
+		}
+		if hasher == nil {
+			return nil, errors.New("notify-updates: no hasher available")
+		}
+
+		binary, err := hashLocalPath(hasher, exe)
+		if err != nil {
+			return nil, errors.Wrap(err, "notify-updates: failed to hash running binary")
+		}
+
+		check.Binary = binary.String()
+	}
+
+	// what is known of the order of releases carries over from the previous
+	// check; a binary that replaced another is newer than it.
+	if cached != nil {
+		check.Previous = cached.Previous
+		if cached.Binary == check.Binary {
+			check.Released = cached.Released
+		} else if cached.Binary != "" {
+			check.Previous = appendUnique(check.Previous, cached.Binary)
+		}
+	}
+
+	publisher, err := app.Providers.ParseIdentity(p.Publisher)
+	if err != nil {
+		return nil, errors.Wrap(err, "notify-updates: failed to parse publisher")
+	}
+
+	pub := app.publish()
+	publication, err := pub.GetPublications(publisher)
+	if err != nil {
+		return nil, errors.Wrap(err, "notify-updates: failed to resolve publication")
+	}
+	check.Publication = publication.String()
+
+	if len(publication.Multihash) > 0 {
+		release, err := pub.LoadRelease(publication, channel)
+		if err != nil {
+			return nil, errors.Wrap(err, "notify-updates: failed to load release")
+		}
+
+		latest, ok := release[publish.Platform()]
+		if ok {
+			check.Latest = latest.String()
+		}
+	}
+
+	if check.Latest == check.Binary {
+		check.Released = true
+	}
+
+	err = saveUpdateCheck(path, check)
+	if err != nil {
+		return nil, err
+	}
+
+	return check, nil
+}
+
+// appendUnique appends `value` to `values` unless it is already present
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+}
+
+// cachePath returns the path of the file in which checks of `channel` are
+// cached.
+func (p *NotifyUpdates) cachePath(app *App, channel string) (string, error) {
+	dir := p.CacheDir
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", errors.Wrap(err, "notify-updates: failed to find cache dir")
+		}
+
+		dir = filepath.Join(cache, "dapp", "updates")
+	}
+
+	return filepath.Join(dir, app.ID+"."+channel+".json"), nil
+}
+
+func loadUpdateCheck(path string) (*updateCheck, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "notify-updates: failed to read cache")
+	}
+
+	var check updateCheck
+	err = json.Unmarshal(raw, &check)
+	if err != nil {
+		return nil, errors.Wrap(err, "notify-updates: failed to parse cache")
+	}
+
+	return &check, nil
+}
+
+func saveUpdateCheck(path string, check *updateCheck) error {
+	raw, err := json.Marshal(check)
+	if err != nil {
+		return errors.Wrap(err, "notify-updates: failed to encode cache")
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return errors.Wrap(err, "notify-updates: failed to create cache dir")
+	}
+
+	err = ioutil.WriteFile(path, raw, 0600)
+	if err != nil {
+		return errors.Wrap(err, "notify-updates: failed to write cache")
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/dappstore/go-dapp/protocols/hash"
+	"github.com/dappstore/go-dapp/protocols/publish"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ DependentPolicy = &NotifyUpdates{}
+
+// countingHasher counts the paths it hashes
+type countingHasher struct {
+	hash.Hasher
+	count int
+}
+
+func (h *countingHasher) HashLocalPath(path string) dapp.Hash {
+	h.count++
+	return h.Hasher.HashLocalPath(path)
+}
+
+func TestNotifyUpdates_check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-notify")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client := mem.New()
+	hasher := &countingHasher{Hasher: client}
+	app := &App{ID: "notify"}
+	app.Providers.IdentityProvider = client
+	app.Providers.KV = client
+	app.Providers.Store = client
+
+	publisher, err := client.RandomIdentity()
+	require.NoError(t, err)
+	p := &NotifyUpdates{
+		Publisher: publisher.PublicKey(),
+		Interval:  time.Hour,
+		CacheDir:  filepath.Join(dir, "cache"),
+		Hasher:    hasher,
+	}
+
+	write := func(name string, contents string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0755))
+		return path
+	}
+
+	release := func(binary dapp.Hash) {
+		pubdir := filepath.Join(dir, "publication-"+binary.String())
+		require.NoError(t, os.MkdirAll(filepath.Join(pubdir, publish.ChannelsPath), 0755))
+		raw, err := json.Marshal(map[string]string{publish.Platform(): binary.String()})
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(
+			filepath.Join(pubdir, publish.ChannelsPath, publish.DefaultChannel), raw, 0644,
+		))
+
+		publication, err := client.StorePath(pubdir)
+		require.NoError(t, err)
+		_, err = client.Set(publisher, "dapp:publications", publication.Bytes())
+		require.NoError(t, err)
+	}
+
+	v1 := write("v1", "v1")
+	v2 := write("v2", "v2")
+	now := time.Now()
+
+	// nothing published
+	check, err := p.check(app, v1, now)
+	require.NoError(t, err)
+	assert.Equal(t, "", check.notice())
+	assert.Equal(t, 1, hasher.count)
+
+	// the running version is the latest, but the previous check is cached
+	release(client.HashLocalPath(v1))
+	check, err = p.check(app, v1, now)
+	require.NoError(t, err)
+	assert.Equal(t, "", check.Latest)
+
+	// the unchanged binary is not hashed again once the cache expires
+	check, err = p.check(app, v1, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, check.Binary, check.Latest)
+	assert.True(t, check.Released)
+	assert.Equal(t, "", check.notice())
+	assert.Equal(t, 1, hasher.count)
+
+	// a newer version is noticed once the cache expires
+	release(client.HashLocalPath(v2))
+	check, err = p.check(app, v1, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, check.Newer())
+
+	check, err = p.check(app, v1, now.Add(4*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, check.Newer())
+	assert.Equal(t, publish.DefaultChannel, check.Channel)
+	assert.Contains(t, check.notice(), "new version")
+	assert.Equal(t, 1, hasher.count)
+
+	// a different binary ignores the cache, and replaces the one before it
+	check, err = p.check(app, v2, now.Add(4*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "", check.notice())
+	assert.Equal(t, []string{client.HashLocalPath(v1).String()}, check.Previous)
+	assert.Equal(t, 2, hasher.count)
+
+	// an older version is not offered as an update
+	release(client.HashLocalPath(v1))
+	check, err = p.check(app, v2, now.Add(6*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, check.Older())
+	assert.False(t, check.Newer())
+	assert.Equal(t, "", check.notice())
+
+	// a version of unknown order is only reported as different
+	p.CacheDir = filepath.Join(dir, "other")
+	check, err = p.check(app, v2, now)
+	require.NoError(t, err)
+	assert.False(t, check.Older())
+	assert.False(t, check.Newer())
+	assert.Contains(t, check.notice(), "different version")
+}
+
+func TestNotifyUpdates_ApplyDappPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-notify")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client := mem.New()
+	app := &App{ID: "notify"}
+	app.Providers.IdentityProvider = client
+	app.Providers.KV = client
+	app.Providers.Store = client
+
+	publisher, err := client.RandomIdentity()
+	require.NoError(t, err)
+	p := &NotifyUpdates{Publisher: publisher.PublicKey(), CacheDir: dir}
+
+	// the check is made in the background while the app runs, and stops when
+	// the app shuts down
+	require.NoError(t, p.ApplyDappPolicy(app))
+	path, err := p.cachePath(app, publish.DefaultChannel)
+	require.NoError(t, err)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	err = app.Run(context.Background(), func(ctx context.Context) error {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, err := os.Stat(path); err == nil {
+				return nil
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		return errors.New("update check was not made")
+	})
+	require.NoError(t, err)
+
+	check, err := loadUpdateCheck(path)
+	require.NoError(t, err)
+	assert.Equal(t, publish.DefaultChannel, check.Channel)
+}
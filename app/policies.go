@@ -1,28 +1,131 @@
 package app
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/dappstore/go-dapp"
 	"github.com/dappstore/go-dapp/ipfs"
+	"github.com/dappstore/go-dapp/local"
 	"github.com/dappstore/go-dapp/protocols/claim"
+	"github.com/dappstore/go-dapp/protocols/hash"
+	"github.com/dappstore/go-dapp/protocols/publish"
+	"github.com/dappstore/go-dapp/session"
 	"github.com/dappstore/go-dapp/stellar"
 	"github.com/pkg/errors"
 )
 
 // DefaultProviders is a policy that sets the core providers to the dapp system
 // to the defaults, namely using stellar for id and kv providers, and ipfs for
-// the store provider.
-var DefaultProviders = NewPolicy("default-providers",
-	&Store{Store: ipfs.DefaultClient},
-	&KV{KV: stellar.DefaultClient},
-	&IdentityProvider{IdentityProvider: stellar.DefaultClient},
-)
+// the store provider.  Any provider can be replaced by selecting a uri with the
+// `-dapp.store`, `-dapp.kv` and `-dapp.identity` flags (or the DAPP_STORE,
+// DAPP_KV and DAPP_IDENTITY environment variables).  In developer mode, the
+// DevProviders policy is applied instead.
+var DefaultProviders = WithDependencies(&fnPolicy{
+	"default-providers",
+	func(app *App) error {
+		if dapp.DevMode() {
+			return DevProviders.ApplyDappPolicy(app)
+		}
+
+		return (&ProviderURIs{
+			Store:    dapp.StoreURI(),
+			KV:       dapp.KVURI(),
+			Identity: dapp.IdentityURI(),
+		}).ApplyDappPolicy(app)
+	},
+}, nil, providerDeps)
+
+// DevProviders is a policy that sets the core providers to local stand-ins
+// that store their state in a directory in the user's cache, allowing an app to
+// run without an ipfs daemon or horizon server.  It claims `dapp.dev`.
+var DevProviders = WithDependencies(&fnPolicy{
+	"dev-providers",
+	func(app *App) error {
+		dir, err := local.DefaultDir(app.ID)
+		if err != nil {
+			return errors.Wrap(err, "dev-providers: failed to find state dir")
+		}
+
+		client, err := local.NewDir(dir)
+		if err != nil {
+			return errors.Wrap(err, "dev-providers: failed to create local client")
+		}
+
+		err = NewPolicy("local-providers",
+			&Store{Store: client},
+			&KV{KV: client},
+			&IdentityProvider{IdentityProvider: client},
+		).ApplyDappPolicy(app)
+		if err != nil {
+			return err
+		}
+
+		err = app.Claims().Make("dapp.dev", true)
+		if err != nil {
+			return errors.Wrap(err, "dev-providers: failed to claim dapp.dev")
+		}
+
+		return nil
+	},
+}, nil, providerDeps)
+
+var providerDeps = []string{DepStore, DepKV, DepIdentityProvider}
+
+// ClaimVersion is a policy that claims the version of the running binary as
+// `dapp.version`.  NewApp applies it to every app.
+var ClaimVersion = &fnPolicy{
+	"claim-version",
+	func(app *App) error {
+		err := app.Claims().Make("dapp.version", dapp.Version())
+		if err != nil {
+			return errors.Wrap(err, "claim-version: failed to claim dapp.version")
+		}
+
+		return nil
+	},
+}
+
+// ErrPrinted is returned by NewApp once the PrintID or PrintVersion policy has
+// printed what was requested.  Use ShouldExit to match it.
+var ErrPrinted = errors.New("dapp: printed requested info")
+
+// PrintID is a policy that prints the app's id when the `-dapp.id` flag is
+// provided, failing with ErrPrinted such that the program can exit.
+var PrintID = &fnPolicy{
+	"print-id",
+	func(app *App) error {
+		if !dapp.IDRequested() {
+			return nil
+		}
+
+		fmt.Println(app.ID)
+		return ErrPrinted
+	},
+}
+
+// PrintVersion is a policy that prints the version of the running binary when
+// the `-dapp.version` flag is provided, failing with ErrPrinted such that the
+// program can exit.
+var PrintVersion = &fnPolicy{
+	"print-version",
+	func(app *App) error {
+		if !dapp.VersionRequested() {
+			return nil
+		}
+
+		fmt.Println(dapp.Version())
+		return ErrPrinted
+	},
+}
 
 // Description is a policy that claims the application's description as `desc`
 func Description(desc string) Policy {
 	return &fnPolicy{
 		"set-description",
 		func(app *App) error {
-			err := claim.Make("dapp.description", desc)
+			err := app.Claims().Make("dapp.description", desc)
 			if err != nil {
 				return errors.Wrap(err, "set-developer: failed to claim dapp.description")
 			}
@@ -32,27 +135,25 @@ func Description(desc string) Policy {
 	}
 }
 
-// Developer is a policy that claims the developer identity is `id`.
+// Developer is a policy that claims the developer identity is `id`.  It
+// requires an identity provider.
 func Developer(id string) Policy {
-	return &fnPolicy{
+	return WithDependencies(&fnPolicy{
 		"set-developer",
 		func(app *App) error {
-
-			ids := app.Providers
-			did, err := ids.ParseIdentity(id)
-			if app.Providers.IdentityProvider != nil {
+			did, err := app.Providers.ParseIdentity(id)
+			if err != nil {
 				return errors.Wrap(err, "set-developer: failed to parse id")
 			}
 
-			err = claim.Make("dapp.developer", did)
+			err = app.Claims().Make("dapp.developer", did)
 			if err != nil {
 				return errors.Wrap(err, "set-developer: failed to claim dapp.developer")
 			}
 
 			return nil
-
 		},
-	}
+	}, []string{DepIdentityProvider}, nil)
 }
 
 // Name is a policy that claims
@@ -60,7 +161,7 @@ func Name(name string) Policy {
 	return &fnPolicy{
 		"set-name",
 		func(app *App) error {
-			err := claim.Make("dapp.name", name)
+			err := app.Claims().Make("dapp.name", name)
 			if err != nil {
 				return errors.Wrap(err, "set-name: failed to claim dapp.name")
 			}
@@ -70,11 +171,46 @@ func Name(name string) Policy {
 	}
 }
 
+// TrustRoots is a policy that claims the identities in `ids` as the roots of
+// trust for the app, as `dapp.trust.roots`.  It requires an identity provider.
+func TrustRoots(ids ...string) Policy {
+	return WithDependencies(&fnPolicy{
+		"set-trust-roots",
+		func(app *App) error {
+			roots := make([]string, len(ids))
+			for i, id := range ids {
+				root, err := app.Providers.ParseIdentity(id)
+				if err != nil {
+					return errors.Wrap(err, "set-trust-roots: failed to parse id")
+				}
+
+				roots[i] = root.PublicKey()
+			}
+
+			err := app.Claims().Make("dapp.trust.roots", roots)
+			if err != nil {
+				return errors.Wrap(err, "set-trust-roots: failed to claim dapp.trust.roots")
+			}
+
+			return nil
+		},
+	}, []string{DepIdentityProvider}, nil)
+}
+
 // IdentityProvider is a policy that registers an identity system.
 type IdentityProvider struct {
 	dapp.IdentityProvider
 }
 
+// PolicyProvides implements `DependentPolicy`
+func (p *IdentityProvider) PolicyProvides() []string { return []string{DepIdentityProvider} }
+
+// PolicyRequires implements `DependentPolicy`
+func (p *IdentityProvider) PolicyRequires() []string { return nil }
+
+// PolicyName implements `NamedPolicy`
+func (p *IdentityProvider) PolicyName() string { return "identity-provider" }
+
 // ApplyDappPolicy implements `Policy`
 func (p *IdentityProvider) ApplyDappPolicy(app *App) error {
 	if app.Providers.IdentityProvider != nil {
@@ -86,7 +222,7 @@ func (p *IdentityProvider) ApplyDappPolicy(app *App) error {
 	}
 
 	app.Providers.IdentityProvider = p.IdentityProvider
-	return addClaimer(p.IdentityProvider)
+	return addClaimer(app, p.IdentityProvider)
 }
 
 // KV is a policy that registers a decentralized key value store when applied.
@@ -94,6 +230,15 @@ type KV struct {
 	dapp.KV
 }
 
+// PolicyProvides implements `DependentPolicy`
+func (p *KV) PolicyProvides() []string { return []string{DepKV} }
+
+// PolicyRequires implements `DependentPolicy`
+func (p *KV) PolicyRequires() []string { return nil }
+
+// PolicyName implements `NamedPolicy`
+func (p *KV) PolicyName() string { return "kv" }
+
 // ApplyDappPolicy implements `Policy`
 func (p *KV) ApplyDappPolicy(app *App) error {
 	if app.Providers.KV != nil {
@@ -105,23 +250,159 @@ func (p *KV) ApplyDappPolicy(app *App) error {
 	}
 
 	app.Providers.KV = p.KV
-	return addClaimer(p.KV)
+	return addClaimer(app, p.KV)
+}
+
+// ProviderURIs is a policy that registers the core providers selected by
+// uri, using the providers registered with dapp.RegisterProvider.  An empty
+// uri selects the default provider, and providers selected by the same uri are
+// shared.
+type ProviderURIs struct {
+	Store    string
+	KV       string
+	Identity string
+}
+
+// PolicyName implements `NamedPolicy`
+func (p *ProviderURIs) PolicyName() string { return "provider-uris" }
+
+// PolicyProvides implements `DependentPolicy`
+func (p *ProviderURIs) PolicyProvides() []string { return providerDeps }
+
+// PolicyRequires implements `DependentPolicy`
+func (p *ProviderURIs) PolicyRequires() []string { return nil }
+
+// ApplyDappPolicy implements `Policy`
+func (p *ProviderURIs) ApplyDappPolicy(app *App) error {
+	opened := map[string]interface{}{}
+	open := func(uri string, fallback interface{}) (interface{}, error) {
+		if uri == "" {
+			return fallback, nil
+		}
+
+		provider, ok := opened[uri]
+		if ok {
+			return provider, nil
+		}
+
+		provider, err := dapp.OpenProvider(uri)
+		if err != nil {
+			return nil, err
+		}
+
+		opened[uri] = provider
+		return provider, nil
+	}
+
+	store, err := open(p.Store, ipfs.DefaultClient)
+	if err != nil {
+		return errors.Wrap(err, "provider-uris: failed to open store")
+	}
+
+	kv, err := open(p.KV, stellar.DefaultClient)
+	if err != nil {
+		return errors.Wrap(err, "provider-uris: failed to open kv")
+	}
+
+	ids, err := open(p.Identity, stellar.DefaultClient)
+	if err != nil {
+		return errors.Wrap(err, "provider-uris: failed to open identity provider")
+	}
+
+	sp, ok := store.(dapp.Store)
+	if !ok {
+		return errors.Errorf("provider-uris: %s is not a store", p.Store)
+	}
+
+	kp, ok := kv.(dapp.KV)
+	if !ok {
+		return errors.Errorf("provider-uris: %s is not a kv", p.KV)
+	}
+
+	ip, ok := ids.(dapp.IdentityProvider)
+	if !ok {
+		return errors.Errorf("provider-uris: %s is not an identity provider", p.Identity)
+	}
+
+	return NewPolicy("uri-providers",
+		&Store{Store: sp},
+		&KV{KV: kp},
+		&IdentityProvider{IdentityProvider: ip},
+	).ApplyDappPolicy(app)
 }
 
 // RunVerification represents the dapp policy that actually runs the process
 // verification protocol.
 type RunVerification struct{}
 
+// PolicyName implements `NamedPolicy`
+func (p *RunVerification) PolicyName() string { return "run-verification" }
+
 // ApplyDappPolicy applies `p` to `app`
 func (p *RunVerification) ApplyDappPolicy(app *App) error {
 	return nil
 }
 
+// SessionPassphraseEnv is the environment variable consulted for the session
+// passphrase when the Sessions policy does not specify one.
+const SessionPassphraseEnv = "DAPP_SESSION_PASSPHRASE"
+
+// Sessions is a policy that persists the app's logged in user between runs in
+// an encrypted session file in `Dir` (session.DefaultDir() if empty).  The
+// session is encrypted with `Passphrase`, falling back to the value of
+// SessionPassphraseEnv, and expires after `TTL` unless it is zero.
+type Sessions struct {
+	Dir        string
+	Passphrase string
+	TTL        time.Duration
+}
+
+// PolicyName implements `NamedPolicy`
+func (p *Sessions) PolicyName() string { return "sessions" }
+
+// ApplyDappPolicy implements `Policy`
+func (p *Sessions) ApplyDappPolicy(app *App) error {
+	if app.sessions != nil {
+		return errors.New("policy: cannot overwrite session store")
+	}
+
+	dir := p.Dir
+	if dir == "" {
+		var err error
+		dir, err = session.DefaultDir()
+		if err != nil {
+			return errors.Wrap(err, "sessions-policy: failed to find session dir")
+		}
+	}
+
+	passphrase := p.Passphrase
+	if passphrase == "" {
+		passphrase = os.Getenv(SessionPassphraseEnv)
+	}
+
+	if passphrase == "" {
+		return errors.New("sessions-policy: no passphrase configured")
+	}
+
+	app.sessions = session.New(dir, []byte(passphrase), p.TTL)
+	app.OnRollback(func() { app.sessions = nil })
+	return nil
+}
+
 // Store is a policy that registers a content addressable store when applied
 type Store struct {
 	dapp.Store
 }
 
+// PolicyProvides implements `DependentPolicy`
+func (p *Store) PolicyProvides() []string { return []string{DepStore} }
+
+// PolicyRequires implements `DependentPolicy`
+func (p *Store) PolicyRequires() []string { return nil }
+
+// PolicyName implements `NamedPolicy`
+func (p *Store) PolicyName() string { return "store" }
+
 // ApplyDappPolicy implements `Policy`
 func (p *Store) ApplyDappPolicy(app *App) error {
 	if app.Providers.Store != nil {
@@ -133,38 +414,208 @@ func (p *Store) ApplyDappPolicy(app *App) error {
 	}
 
 	// TODO
-	// err := claim.Make("dapp.providers.store", p.Store.Identity())
+	// err := app.Claims().Make("dapp.providers.store", p.Store.Identity())
 	// if err != nil {
 	// 	return errors.Wrap(err, "store-policy: could not main claim")
 	// }
 
 	app.Providers.Store = p.Store
-	return addClaimer(p.Store)
+	return addClaimer(app, p.Store)
+}
+
+// VerificationClaimPath is the claim path at which the result of the
+// VerifySelf policy is recorded.
+const VerificationClaimPath = "dapp.verification"
+
+// The possible statuses of a self verification.  A binary is unverifiable
+// when it cannot be hashed, and unpublished when the publisher has published
+// no binary for the running platform on the channel, neither of which says
+// anything about whether it was tampered with.
+const (
+	VerificationVerified     = "verified"
+	VerificationMismatch     = "mismatch"
+	VerificationUnverifiable = "unverifiable"
+	VerificationUnpublished  = "unpublished"
+	VerificationUnreachable  = "publisher-unreachable"
+	VerificationSkipped      = "skipped"
+)
+
+// Verification is the claim made by the VerifySelf policy.
+type Verification struct {
+	Status    string
+	Publisher string
+	Channel   string
+	Platform  string
+	Expected  string `json:",omitempty"`
+	Actual    string `json:",omitempty"`
+	Error     string `json:",omitempty"`
 }
 
 // VerifySelf is a policy that causes the binary to verify itself as an
 // installation of the application published by `Publisher`, according to the
-// dapp publisher protocol.
+// dapp publisher protocol.  The running binary is hashed using `Hasher`
+// (falling back to the app's store if it can hash), and compared with the
+// binary for the running platform on `Channel` (publish.DefaultChannel if
+// empty).  When `Strict` is true, a failed verification fails the policy.
+// Verification is skipped in developer mode and during dry runs.
 type VerifySelf struct {
 	Publisher string
+	Channel   string
+	Hasher    hash.Hasher
+	Strict    bool
 }
 
+// PolicyProvides implements `DependentPolicy`
+func (p *VerifySelf) PolicyProvides() []string { return nil }
+
+// PolicyRequires implements `DependentPolicy`
+func (p *VerifySelf) PolicyRequires() []string { return providerDeps }
+
+// PolicyName implements `NamedPolicy`
+func (p *VerifySelf) PolicyName() string { return "verify-self" }
+
 // ApplyDappPolicy applies `p` to `app`
 func (p *VerifySelf) ApplyDappPolicy(app *App) error {
-	claim.Push("dapp.vulnerabilities", "policy-verify-self: not implemented")
+	result := p.verify(app)
+
+	err := app.Claims().Make(VerificationClaimPath, result)
+	if err != nil {
+		return errors.Wrap(err, "policy-verify-self: failed to claim verification")
+	}
+
+	if p.Strict && result.Status != VerificationVerified &&
+		result.Status != VerificationSkipped {
+		return errors.Errorf("policy-verify-self: verification failed: %s", result.Status)
+	}
+
 	return nil
 }
 
-func addClaimer(c interface{}) error {
+func (p *VerifySelf) verify(app *App) (result Verification) {
+	result.Publisher = p.Publisher
+	result.Channel = p.Channel
+	result.Platform = publish.Platform()
+	if result.Channel == "" {
+		result.Channel = publish.DefaultChannel
+	}
+
+	if dapp.DevMode() || app.DryRun() {
+		result.Status = VerificationSkipped
+		return
+	}
+
+	hasher := p.Hasher
+	if hasher == nil {
+		hasher = app.storeHasher()
+	}
+	if hasher == nil {
+		result.Status = VerificationUnverifiable
+		result.Error = "no hasher available"
+		return
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		result.Status = VerificationUnverifiable
+		result.Error = errors.Wrap(err, "failed to find executable").Error()
+		return
+	}
+
+	actual, err := hashLocalPath(hasher, exe)
+	if err != nil {
+		result.Status = VerificationUnverifiable
+		result.Error = err.Error()
+		return
+	}
+	result.Actual = actual.String()
+
+	expected, err := p.publishedBinary(app, result.Channel)
+	if err != nil {
+		result.Status = VerificationUnreachable
+		result.Error = err.Error()
+		return
+	}
+
+	if expected == nil {
+		result.Status = VerificationUnpublished
+		result.Error = "no binary published for platform"
+		return
+	}
+	result.Expected = expected.String()
+
+	if !actual.Equals(*expected) {
+		result.Status = VerificationMismatch
+		return
+	}
+
+	result.Status = VerificationVerified
+	return
+}
+
+// publishedBinary returns the hash of the binary for the running platform
+// published on `channel`, or nil if the publisher has published nothing, has
+// not published the channel or has published no binary for the platform.
+func (p *VerifySelf) publishedBinary(
+	app *App,
+	channel string,
+) (*dapp.Hash, error) {
+	publisher, err := app.Providers.ParseIdentity(p.Publisher)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse publisher")
+	}
+
+	pub := app.publish()
+	publication, err := pub.GetPublications(publisher)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve publication")
+	}
+
+	if len(publication.Multihash) == 0 {
+		return nil, nil
+	}
+
+	release, err := pub.LoadRelease(publication, channel)
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load release")
+	}
+
+	expected, ok := release[publish.Platform()]
+	if !ok {
+		return nil, nil
+	}
+
+	return &expected, nil
+}
+
+// addClaimer registers `c` as a claimer on the app's claim protocol if it makes
+// claims.
+func addClaimer(app *App, c interface{}) error {
 	claimer, ok := c.(claim.MakesClaims)
 	if !ok {
 		return nil
 	}
 
-	err := claim.AddClaimer(claimer)
+	err := app.Claims().AddClaimer(claimer)
 	if err != nil {
 		return errors.Wrap(err, "store-policy: failed to add claimer")
 	}
 
 	return nil
 }
+
+// hashLocalPath hashes `path` using `h`, converting a panicking hasher into an
+// error.
+func hashLocalPath(h hash.Hasher, path string) (result dapp.Hash, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("hasher failed: %v", r)
+		}
+	}()
+
+	result = h.HashLocalPath(path)
+	return
+}
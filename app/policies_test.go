@@ -1,10 +1,162 @@
 package app
 
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/dappstore/go-dapp/protocols/publish"
+	"github.com/dappstore/go-dapp/stellar"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
 var _ Policy = &IdentityProvider{}
 var _ Policy = &KV{}
+var _ Policy = &ProviderURIs{}
 var _ Policy = &RunVerification{}
+var _ Policy = &SelfUpdate{}
+var _ Policy = &Sessions{}
+var _ Policy = &Instrument{}
 var _ Policy = &Store{}
 var _ Policy = &VerifySelf{}
+var _ Policy = DefaultProviders
+var _ Policy = DevProviders
+var _ DependentPolicy = &KV{}
+var _ DependentPolicy = &compositePolicy{}
 var _ Policy = Name("me")
+var _ Policy = ClaimVersion
+var _ Policy = PrintID
+var _ Policy = PrintVersion
 var _ Policy = Developer("GSDSED")
 var _ Policy = Description("It just spins")
+var _ Policy = TrustRoots("GSDSED")
+var _ Policy = ClaimsFile("claims.json")
+
+type panicHasher struct{}
+
+func (h panicHasher) HashLocalPath(path string) dapp.Hash {
+	panic("hashing unavailable")
+}
+
+func TestVerifySelf_verify(t *testing.T) {
+	app := &App{ID: "GDGIXJPUTJIYHHJ2TYWO2HJMFNT7M767ZB33SFGTD77JUE3YZ6YZBUD4"}
+
+	// a failing hasher leaves the binary unverifiable, not mismatched or
+	// crashed
+	p := &VerifySelf{Publisher: app.ID, Hasher: panicHasher{}}
+	result := p.verify(app)
+	assert.Equal(t, VerificationUnverifiable, result.Status)
+	assert.Equal(t, "stable", result.Channel)
+	assert.Contains(t, result.Error, "hashing unavailable")
+
+	// without a hasher or a store that can hash, verification fails
+	p = &VerifySelf{Publisher: app.ID, Channel: "beta"}
+	result = p.verify(app)
+	assert.Equal(t, VerificationUnverifiable, result.Status)
+	assert.Equal(t, "beta", result.Channel)
+}
+
+func TestVerifySelf_published(t *testing.T) {
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "dapp-verify")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client := mem.New()
+	binary := client.HashLocalPath(exe)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other"), []byte("other"), 0755))
+	other := client.HashLocalPath(filepath.Join(dir, "other"))
+
+	cases := []struct {
+		name     string
+		manifest map[string]string // nil publishes nothing
+		channel  bool              // whether the channel is published
+		status   string
+	}{
+		{"nothing published", nil, false, VerificationUnpublished},
+		{"channel unpublished", map[string]string{}, false, VerificationUnpublished},
+		{"platform unpublished", map[string]string{"plan9-386": binary.String()}, true, VerificationUnpublished},
+		{"other binary", map[string]string{publish.Platform(): other.String()}, true, VerificationMismatch},
+		{"this binary", map[string]string{publish.Platform(): binary.String()}, true, VerificationVerified},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			publisher, err := client.RandomIdentity()
+			require.NoError(t, err)
+
+			if c.manifest != nil {
+				dir := filepath.Join(dir, c.name)
+
+				channels := filepath.Join(dir, publish.ChannelsPath)
+				require.NoError(t, os.MkdirAll(channels, 0755))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte(c.name), 0644))
+				if c.channel {
+					raw, err := json.Marshal(c.manifest)
+					require.NoError(t, err)
+					require.NoError(t, ioutil.WriteFile(
+						filepath.Join(channels, publish.DefaultChannel), raw, 0644,
+					))
+				}
+
+				publication, err := client.StorePath(dir)
+				require.NoError(t, err)
+				_, err = client.Set(publisher, "dapp:publications", publication.Bytes())
+				require.NoError(t, err)
+			}
+
+			app := &App{ID: "verify"}
+			app.Providers.IdentityProvider = client
+			app.Providers.KV = client
+			app.Providers.Store = client
+
+			p := &VerifySelf{Publisher: publisher.PublicKey(), Hasher: client}
+			result := p.verify(app)
+			assert.Equal(t, c.status, result.Status, result.Error)
+		})
+	}
+}
+
+func TestProviderURIs(t *testing.T) {
+	app := &App{ID: "uris"}
+
+	require.NoError(t, app.ApplyPolicy(&ProviderURIs{
+		Store: "mem://",
+		KV:    "mem://",
+	}))
+
+	// the same uri selects the same provider, and empty uris the default
+	assert.IsType(t, &mem.Client{}, app.Providers.Store)
+	assert.Equal(t, app.Providers.Store, app.Providers.KV)
+	assert.Equal(t, stellar.DefaultClient, app.Providers.IdentityProvider)
+
+	// unknown schemes fail
+	app = &App{ID: "uris"}
+	assert.Error(t, app.ApplyPolicy(&ProviderURIs{Store: "floppy://"}))
+	assert.Nil(t, app.Providers.Store)
+}
+
+func TestPrintVersion(t *testing.T) {
+	require.NoError(t, flag.Set("dapp.version", "true"))
+	defer flag.Set("dapp.version", "false")
+
+	// printing the version reports that the program should exit
+	client := mem.New()
+	_, err := NewApp("print-version",
+		&Store{Store: client},
+		&KV{KV: client},
+		&IdentityProvider{IdentityProvider: client},
+		PrintVersion,
+	)
+	assert.True(t, errors.Is(err, ErrPrinted))
+	assert.True(t, ShouldExit(err))
+}
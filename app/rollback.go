@@ -0,0 +1,96 @@
+package app
+
+import (
+	"github.com/pkg/errors"
+)
+
+// OnRollback registers `fn` to be run should the batch of policies currently
+// being applied to `a` fail.  Policies use it to undo side effects that the
+// app cannot undo on its own; provider assignments, claims, background tasks
+// and shutdown hooks are always rolled back.  Undo steps run in the reverse
+// order they were registered.
+func (a *App) OnRollback(fn func()) {
+	a.undo = append(a.undo, fn)
+}
+
+// keptClaim is a claim that survives the rollback of a failed batch
+type keptClaim struct {
+	path  string
+	value interface{}
+}
+
+// keepClaim makes the claim `value` at `path`, which unlike other claims is
+// not undone should the batch of policies currently being applied fail.
+// Policies use it to record the outcome that failed the batch.
+func (a *App) keepClaim(path string, value interface{}) error {
+	err := a.Claims().Make(path, value)
+	if err != nil {
+		return err
+	}
+
+	a.kept = append(a.kept, keptClaim{path, value})
+	return nil
+}
+
+// transaction runs `fn`, returning the app and its claims to their prior state
+// if it fails.
+func (a *App) transaction(fn func() error) error {
+	claims := a.Claims().Snapshot()
+	providers := a.Providers
+	applied := len(a.policies)
+	kept := len(a.kept)
+
+	a.lifecycle.lock.Lock()
+	tasks := len(a.lifecycle.tasks)
+	hooks := len(a.lifecycle.hooks)
+	a.lifecycle.lock.Unlock()
+
+	outer := a.undo
+	a.undo = nil
+	a.depth++
+	defer func() {
+		a.depth--
+		if a.depth == 0 {
+			a.kept = nil
+		}
+	}()
+
+	err := fn()
+	if err == nil {
+		// a nested batch is undone along with the batch that contains it
+		if a.depth > 1 {
+			a.undo = append(outer, a.undo...)
+		} else {
+			a.undo = nil
+		}
+
+		return nil
+	}
+
+	for i := len(a.undo) - 1; i >= 0; i-- {
+		a.undo[i]()
+	}
+
+	a.undo = outer
+	a.Providers = providers
+	a.policies = a.policies[:applied]
+
+	a.lifecycle.lock.Lock()
+	a.lifecycle.tasks = a.lifecycle.tasks[:tasks]
+	a.lifecycle.hooks = a.lifecycle.hooks[:hooks]
+	a.lifecycle.lock.Unlock()
+
+	rerr := a.Claims().Restore(claims)
+	if rerr != nil {
+		return errors.Wrapf(err, "dapp: rollback failed (%s)", rerr)
+	}
+
+	for _, c := range a.kept[kept:] {
+		rerr = a.Claims().Make(c.path, c.value)
+		if rerr != nil {
+			return errors.Wrapf(err, "dapp: failed to keep claim %s (%s)", c.path, rerr)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_ApplyPolicies_Rollback(t *testing.T) {
+	client := mem.New()
+	app := &App{ID: "rollback"}
+	before := app.Claims().CurrentClaims()
+
+	var undone []string
+	undoable := func(name string) Policy {
+		return &fnPolicy{name, func(app *App) error {
+			app.OnRollback(func() { undone = append(undone, name) })
+			return nil
+		}}
+	}
+
+	failing := &fnPolicy{"failing", func(*App) error {
+		return errors.New("boom")
+	}}
+
+	err := app.ApplyPolicies(
+		&Store{Store: client},
+		undoable("first"),
+		Name("rolled-back"),
+		undoable("second"),
+		failing,
+	)
+	require.Error(t, err)
+
+	assert.Nil(t, app.Providers.Store)
+	assert.Empty(t, app.Policies())
+	assert.Equal(t, before, app.Claims().CurrentClaims())
+	assert.Equal(t, []string{"second", "first"}, undone)
+
+	// a later batch succeeds and is not undone by another failing batch
+	undone = nil
+	require.NoError(t, app.ApplyPolicies(&Store{Store: client}, undoable("kept")))
+	require.Error(t, app.ApplyPolicies(undoable("dropped"), failing))
+	assert.NotNil(t, app.Providers.Store)
+	assert.Len(t, app.Policies(), 2)
+	assert.Equal(t, []string{"dropped"}, undone)
+}
+
+func TestNewApp_Rollback(t *testing.T) {
+	app, err := NewApp("rollback",
+		&Store{Store: mem.New()},
+		Name("rolled-back"),
+		Description("never claimed"),
+	)
+	require.Error(t, err)
+
+	assert.Equal(t, "{}", app.Claims().CurrentClaims())
+}
+
+func TestApp_keepClaim(t *testing.T) {
+	app := &App{ID: "kept"}
+
+	// kept claims survive the rollback of the batch that made them, even
+	// when nested, while the batch's other claims do not
+	err := app.ApplyPolicies(
+		&fnPolicy{"claims", func(app *App) error {
+			require.NoError(t, app.Claims().Make("test.dropped", true))
+			return app.ApplyPolicies(&fnPolicy{"kept", func(app *App) error {
+				require.NoError(t, app.keepClaim("test.kept", "outcome"))
+				return errors.New("boom")
+			}})
+		}},
+	)
+	require.Error(t, err)
+
+	claims := app.Claims().CurrentClaims()
+	assert.Contains(t, claims, `"kept":"outcome"`)
+	assert.NotContains(t, claims, "dropped")
+
+	// a later failing batch does not make them again
+	require.Error(t, app.ApplyPolicies(&fnPolicy{"failing", func(*App) error {
+		return errors.New("boom")
+	}}))
+	assert.Equal(t, claims, app.Claims().CurrentClaims())
+}
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/protocols/dfs"
+	"github.com/dappstore/go-dapp/protocols/hash"
+	"github.com/dappstore/go-dapp/protocols/publish"
+	"github.com/pkg/errors"
+)
+
+// UpdateProbeEnv is the environment variable set when a freshly installed
+// binary is started to check that it runs.  NewApp returns ErrUpdateProbed
+// in a probed binary as soon as its policies have been applied, and the
+// binary should then exit successfully (see ShouldExit).
+const UpdateProbeEnv = "DAPP_UPDATE_PROBE"
+
+// The errors NewApp returns once an update has done all that the process was
+// started to do.  Use ShouldExit to match them.
+var (
+	// ErrUpdateProbed is returned by a binary started with UpdateProbeEnv set
+	ErrUpdateProbed = errors.New("dapp: started successfully for an update probe")
+
+	// ErrUpdated is returned once a one-shot SelfUpdate has completed
+	ErrUpdated = errors.New("dapp: update complete")
+)
+
+// UpdateProbeTimeout is the amount of time a freshly installed binary has to
+// start successfully before the update is rolled back.
+var UpdateProbeTimeout = 30 * time.Second
+
+// executable returns the path of the running binary, which SelfUpdate
+// replaces.  Tests point it at a fixture so as not to replace themselves.
+var executable = os.Executable
+
+// SelfUpdate is a policy that updates the running binary to the latest version
+// published by `Publisher` on `Channel`.  The running and fetched binaries are
+// hashed using `Hasher` (falling back to the app's store if it can hash).
+// Updates are disabled in developer mode and during dry runs.
+//
+// By default, an update is a one-shot operation: once the app's policies have
+// been applied, NewApp runs the app's shutdown hooks and returns ErrUpdated,
+// upon which the program should exit.  When `Channel` is empty, the channel provided by the
+// `-dapp.update` flag is used, and no update occurs when neither is set.
+//
+// When `Auto` is true, the binary is instead updated in place every time the
+// app starts, and the app keeps running; the new binary is used from the next
+// start.  Failing to update only writes a warning to stderr.  Auto updates
+// require a `Channel`, and the `-dapp.update` flag still requests a one-shot
+// update.
+type SelfUpdate struct {
+	Publisher string
+	Channel   string
+	Hasher    hash.Hasher
+	Auto      bool
+}
+
+// PolicyProvides implements `DependentPolicy`
+func (p *SelfUpdate) PolicyProvides() []string { return nil }
+
+// PolicyRequires implements `DependentPolicy`
+func (p *SelfUpdate) PolicyRequires() []string { return providerDeps }
+
+// PolicyName implements `NamedPolicy`
+func (p *SelfUpdate) PolicyName() string { return "self-update" }
+
+// ApplyDappPolicy applies `p` to `app`
+func (p *SelfUpdate) ApplyDappPolicy(app *App) error {
+	if p.Auto && p.Channel == "" {
+		return errors.New("self-update: auto updates require a channel")
+	}
+
+	channel := p.Channel
+	oneShot := !p.Auto
+	if flag := dapp.UpdateChannel(); flag != "" && (p.Auto || channel == "") {
+		channel = flag
+		oneShot = true
+	}
+
+	if channel == "" || dapp.DevMode() || app.DryRun() ||
+		os.Getenv(UpdateProbeEnv) != "" {
+		return nil
+	}
+
+	exe, err := executable()
+	if err != nil {
+		return errors.Wrap(err, "self-update: failed to find executable")
+	}
+
+	updated, err := p.update(app, channel, exe)
+	if err != nil && !oneShot {
+		fmt.Fprintf(os.Stderr, "dapp: failed to update: %s\n", err)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "self-update: failed")
+	}
+
+	switch {
+	case !oneShot:
+		if updated {
+			fmt.Fprintf(os.Stderr,
+				"dapp: updated to the latest %s release, restart to use it\n", channel)
+		}
+		return nil
+	case updated:
+		fmt.Fprintf(os.Stderr, "dapp: updated to the latest %s release\n", channel)
+	default:
+		fmt.Fprintf(os.Stderr, "dapp: already running the latest %s release\n", channel)
+	}
+
+	app.exitAfterUpdate = true
+	app.OnRollback(func() { app.exitAfterUpdate = false })
+	return nil
+}
+
+// update replaces `exe` with the binary for the running platform in the
+// latest release on `channel`, returning false if `exe` is already up to date.
+func (p *SelfUpdate) update(app *App, channel string, exe string) (bool, error) {
+	hasher := p.Hasher
+	if hasher == nil {
+		hasher = app.storeHasher()
+	}
+	if hasher == nil {
+		return false, errors.New("no hasher available")
+	}
+
+	publisher, err := app.Providers.ParseIdentity(p.Publisher)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to parse publisher")
+	}
+
+	pub := app.publish()
+	publication, err := pub.GetPublications(publisher)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to resolve publication")
+	}
+
+	release, err := pub.LoadRelease(publication, channel)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to load release")
+	}
+
+	expected, ok := release[publish.Platform()]
+	if !ok {
+		return false, errors.Errorf("no binary published for %s", publish.Platform())
+	}
+
+	current, err := hashLocalPath(hasher, exe)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to hash running binary")
+	}
+
+	if current.Equals(expected) {
+		return false, nil
+	}
+
+	next, err := dfs.New(app.Providers.Store).LoadTemp(expected)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to fetch binary")
+	}
+	defer os.RemoveAll(next)
+
+	actual, err := hashLocalPath(hasher, next)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to hash fetched binary")
+	}
+
+	if !actual.Equals(expected) {
+		return false, errors.New("fetched binary does not match publication")
+	}
+
+	err = replaceExecutable(exe, next)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to install binary")
+	}
+
+	return true, nil
+}
+
+// replaceExecutable atomically replaces the executable at `exe` with the file
+// at `next`, then starts the new executable with UpdateProbeEnv set.  If the
+// new executable fails to start, the original executable is restored.
+func replaceExecutable(exe string, next string) error {
+	staged := filepath.Join(filepath.Dir(exe), "."+filepath.Base(exe)+".new")
+	backup := filepath.Join(filepath.Dir(exe), "."+filepath.Base(exe)+".old")
+
+	err := copyFile(staged, next, 0755)
+	if err != nil {
+		return errors.Wrap(err, "failed to stage binary")
+	}
+	defer os.Remove(staged)
+
+	os.Remove(backup)
+	err = os.Link(exe, backup)
+	if err != nil {
+		err = copyFile(backup, exe, 0755)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to back up binary")
+	}
+	defer os.Remove(backup)
+
+	err = os.Rename(staged, exe)
+	if err != nil {
+		return errors.Wrap(err, "failed to swap binary")
+	}
+
+	err = probeExecutable(exe)
+	if err != nil {
+		rerr := os.Rename(backup, exe)
+		if rerr != nil {
+			return errors.Wrap(rerr, "failed to restore binary after failed start")
+		}
+
+		return errors.Wrap(err, "new binary failed to start, restored previous")
+	}
+
+	return nil
+}
+
+// probeExecutable runs `exe` with UpdateProbeEnv set, erroring if it fails to
+// exit successfully within UpdateProbeTimeout.
+func probeExecutable(exe string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), UpdateProbeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, exe)
+	cmd.Env = append(os.Environ(), UpdateProbeEnv+"=1")
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "probe failed: %s", out)
+	}
+
+	return nil
+}
+
+func copyFile(dest string, src string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/dappstore/go-dapp/protocols/publish"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readOnlyStore is a store that can load content but refuses to store it
+type readOnlyStore struct {
+	*mem.Client
+}
+
+func (s readOnlyStore) StorePath(path string) (dapp.Hash, error) {
+	return dapp.Hash{}, errors.New("store is read only")
+}
+
+func TestSelfUpdate_update(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-self-update")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client := mem.New()
+	publisher, err := client.RandomIdentity()
+	require.NoError(t, err)
+
+	// publish a binary that starts when probed
+	bin := filepath.Join(dir, "bin")
+	require.NoError(t, ioutil.WriteFile(bin, []byte("#!/bin/sh\nexit 0\n"), 0755))
+	published, err := client.StorePath(bin)
+	require.NoError(t, err)
+
+	pubdir := filepath.Join(dir, "publication")
+	require.NoError(t, os.MkdirAll(filepath.Join(pubdir, publish.ChannelsPath), 0755))
+	manifest, err := json.Marshal(map[string]string{
+		publish.Platform(): published.String(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(pubdir, publish.ChannelsPath, "beta"), manifest, 0644,
+	))
+	publication, err := client.StorePath(pubdir)
+	require.NoError(t, err)
+	_, err = client.Set(publisher, "dapp:publications", publication.Bytes())
+	require.NoError(t, err)
+
+	// binaries are hashed rather than stored
+	app := &App{ID: "self-update"}
+	app.Providers.IdentityProvider = client
+	app.Providers.KV = client
+	app.Providers.Store = readOnlyStore{client}
+	p := &SelfUpdate{Publisher: publisher.PublicKey(), Hasher: client}
+
+	exe := filepath.Join(dir, "exe")
+	require.NoError(t, ioutil.WriteFile(exe, []byte("#!/bin/sh\nexit 1\n"), 0755))
+
+	updated, err := p.update(app, "beta", exe)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, published, client.HashLocalPath(exe))
+
+	// an up to date binary is left alone
+	updated, err = p.update(app, "beta", exe)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	// a failing hasher fails the update rather than crashing
+	p.Hasher = panicHasher{}
+	_, err = p.update(app, "beta", exe)
+	assert.Error(t, err)
+}
+
+func TestReplaceExecutable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-update")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name string, contents string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0755))
+		return path
+	}
+
+	read := func(path string) string {
+		contents, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		return string(contents)
+	}
+
+	exe := write("exe", "#!/bin/sh\nexit 0\n")
+
+	// a binary that starts replaces the executable
+	good := write("good", "#!/bin/sh\n[ -n \"$"+UpdateProbeEnv+"\" ] || exit 1\n")
+	if assert.NoError(t, replaceExecutable(exe, good)) {
+		assert.Equal(t, read(good), read(exe))
+	}
+
+	// a binary that fails to start is rolled back
+	installed := read(exe)
+	bad := write("bad", "#!/bin/sh\nexit 1\n")
+	assert.Error(t, replaceExecutable(exe, bad))
+	assert.Equal(t, installed, read(exe))
+
+	// no staging files are left behind
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+}
+
+func TestNewApp_updateExits(t *testing.T) {
+	client := mem.New()
+	providers := []Policy{
+		&Store{Store: client},
+		&KV{KV: client},
+		&IdentityProvider{IdentityProvider: client},
+	}
+
+	// a probed binary reports that it started rather than exiting
+	require.NoError(t, os.Setenv(UpdateProbeEnv, "1"))
+	_, err := NewApp("probe", providers...)
+	require.NoError(t, os.Unsetenv(UpdateProbeEnv))
+	assert.True(t, errors.Is(err, ErrUpdateProbed))
+	assert.True(t, ShouldExit(err))
+
+	// a one-shot update shuts the app down and reports that it is complete
+	var shutdown bool
+	oneShot := &fnPolicy{"one-shot", func(app *App) error {
+		app.exitAfterUpdate = true
+		app.OnShutdown(func(ctx context.Context) error {
+			shutdown = true
+			return nil
+		})
+		return nil
+	}}
+
+	_, err = NewApp("updated", append(providers, oneShot)...)
+	assert.True(t, errors.Is(err, ErrUpdated))
+	assert.True(t, ShouldExit(err))
+	assert.True(t, shutdown)
+
+	// other failures are not
+	assert.False(t, ShouldExit(errors.New("failed")))
+	assert.False(t, ShouldExit(nil))
+}
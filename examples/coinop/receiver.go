@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	. "github.com/dappstore/go-dapp/app"
 	"log"
+	"time"
 )
 
 // specifying the flag "-dapp.version" causes the binary to output it's version.
@@ -23,7 +25,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	p, err := app.WaitForPayment()
+	p, err := app.WaitForPayment(context.Background(), 10*time.Minute)
 	if err != nil {
 		log.Fatal(err)
 	}
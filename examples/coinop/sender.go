@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	. "github.com/dappstore/go-dapp/app"
 	"log"
@@ -23,13 +24,17 @@ func main() {
 		log.Fatal(err)
 	}
 
-	p, err := app.SendPayment(
+	receiver, err := app.Providers.ParseIdentity(
 		"GDGIXJPUTJIYHHJ2TYWO2HJMFNT7M767ZB33SFGTD77JUE3YZ6YZBUD4",
-		"1.0",
 	)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	p, err := app.SendPayment(context.Background(), receiver, "1.0")
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	fmt.Printf("payment sent in tx %\n", p)
 }
@@ -2,8 +2,6 @@ package ipfs
 
 import (
 	"os"
-	"os/exec"
-	"strings"
 
 	"github.com/dappstore/go-dapp"
 	"github.com/jbenet/go-multihash"
@@ -100,28 +98,29 @@ func (c *Client) add(path string) (multihash.Multihash, error) {
 	return hash, nil
 }
 
+// addDir adds the directory at `path` through the api server, such that remote
+// servers are supported as well as the local daemon.
 func (c *Client) addDir(path string) (string, error) {
-
-	stdout, err := exec.Command("ipfs", "add", "-r", "-q", path).Output()
+	hash, err := c.shell.AddDir(path)
 	if err != nil {
 		return "", errors.Wrap(err, "ipfs: failed to add dir")
 	}
 
-	hashes := strings.Split(strings.TrimSpace(string(stdout)), "\n")
-	lastHash := hashes[len(hashes)-1]
-
-	return lastHash, nil
+	return hash, nil
 }
 
+// addFile adds the file at `path` through the api server
 func (c *Client) addFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrap(err, "ipfs: failed to open file")
+	}
+	defer f.Close()
 
-	stdout, err := exec.Command("ipfs", "add", "-q", path).Output()
+	hash, err := c.shell.Add(f)
 	if err != nil {
 		return "", errors.Wrap(err, "ipfs: failed to add file")
 	}
 
-	hashes := strings.Split(strings.TrimSpace(string(stdout)), "\n")
-	lastHash := hashes[len(hashes)-1]
-
-	return lastHash, nil
+	return hash, nil
 }
@@ -0,0 +1,64 @@
+package ipfs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_StorePath(t *testing.T) {
+	const (
+		fileHash = "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"
+		dirHash  = "QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn"
+	)
+
+	var adds int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v0/version":
+			fmt.Fprint(w, `{"Version":"0.4.5"}`)
+			return
+		case "/api/v0/add":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		adds++
+		if r.URL.Query().Get("recursive") == "true" {
+			fmt.Fprintf(w, `{"Name":"dir/file","Hash":%q}`+"\n", fileHash)
+			fmt.Fprintf(w, `{"Name":"dir","Hash":%q}`+"\n", dirHash)
+			return
+		}
+
+		fmt.Fprintf(w, `{"Name":"file","Hash":%q}`, fileHash)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "ipfs-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(path, []byte("content"), 0644))
+
+	// files and dirs are added through the api server, rather than the local
+	// ipfs cli, such that remote servers can be used
+	c := NewURL(strings.TrimPrefix(srv.URL, "http://"))
+	hash, err := c.StorePath(path)
+	require.NoError(t, err)
+	assert.Equal(t, fileHash, hash.String())
+
+	hash, err = c.StorePath(dir)
+	require.NoError(t, err)
+	assert.Equal(t, dirHash, hash.String())
+	assert.Equal(t, 2, adds)
+}
@@ -0,0 +1,49 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CheckHealth implements dapp.HealthChecker by asking the ipfs api server for
+// its version.
+func (c *Client) CheckHealth(ctx context.Context) error {
+	url := c.url
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+
+	req, err := http.NewRequest("POST", url+"/api/v0/version", nil)
+	if err != nil {
+		return errors.Wrap(err, "ipfs: failed to create version request")
+	}
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "ipfs: api server unreachable")
+	}
+	defer resp.Body.Close()
+
+	if !(resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return errors.Errorf("ipfs: api server responded with %s", resp.Status)
+	}
+
+	var version struct {
+		Version string
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&version)
+	if err != nil {
+		return errors.Wrap(err, "ipfs: failed to decode version")
+	}
+
+	if version.Version == "" {
+		return errors.New("ipfs: api server did not report a version")
+	}
+
+	return nil
+}
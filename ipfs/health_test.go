@@ -0,0 +1,32 @@
+package ipfs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_CheckHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v0/version" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprint(w, `{"Version":"0.4.5"}`)
+	}))
+	defer srv.Close()
+
+	// the api address may omit the scheme, as the ipfs cli does
+	c := NewURL(strings.TrimPrefix(srv.URL, "http://"))
+	assert.NoError(t, c.CheckHealth(context.Background()))
+
+	// an unreachable api server is unhealthy
+	srv.Close()
+	assert.Error(t, c.CheckHealth(context.Background()))
+}
@@ -2,8 +2,10 @@ package ipfs
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
+	"github.com/dappstore/go-dapp"
 	iapi "github.com/ipfs/go-ipfs-api"
 	"github.com/jbenet/go-multihash"
 )
@@ -11,14 +13,20 @@ import (
 // DefaultClient is the default client
 var DefaultClient = New()
 
-// Client uses the ipfs cli app
+// Client uses the ipfs api server
 type Client struct {
 	shell *iapi.Shell
+	url   string
 }
 
 // New creates a new ipfs client
 func New() *Client {
-	return &Client{iapi.NewShell("localhost:5001")}
+	return NewURL("localhost:5001")
+}
+
+// NewURL creates a new ipfs client that uses the api server at `url`
+func NewURL(url string) *Client {
+	return &Client{shell: iapi.NewShell(url), url: url}
 }
 
 // Exists checks to see if `base` has a child named `child` in ipfs
@@ -33,3 +41,14 @@ func Join(base multihash.Multihash, dirs ...string) string {
 		"/",
 	)
 }
+
+func init() {
+	// ipfs://127.0.0.1:5001 selects the api server at the given address
+	dapp.RegisterProvider("ipfs", func(u *url.URL) (interface{}, error) {
+		if u.Host == "" {
+			return New(), nil
+		}
+
+		return NewURL(u.Host), nil
+	})
+}
@@ -10,3 +10,4 @@ import (
 var _ hash.Hasher = ipfs.DefaultClient
 var _ claim.MakesClaims = ipfs.DefaultClient
 var _ dapp.Store = ipfs.DefaultClient
+var _ dapp.HealthChecker = ipfs.DefaultClient
@@ -0,0 +1,85 @@
+// Package kvtest implements a conformance test for implementations of
+// dapp.KV, including the optional dapp.KVDeleter and dapp.KVLister
+// capabilities.
+package kvtest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestKV tests that `kv` behaves as a dapp.KV, using the keys of `identity`
+// beginning with "kvtest:".  The delete and list capabilities are tested when
+// `kv` implements them.
+func TestKV(t *testing.T, kv dapp.KV, identity dapp.Identity) {
+	get := func(key string) []byte {
+		value, err := kv.Get(identity, key)
+		require.NoError(t, err)
+		return value
+	}
+
+	set := func(key string, value []byte) {
+		_, err := kv.Set(identity, key, value)
+		require.NoError(t, err)
+	}
+
+	// missing keys have no value
+	assert.Empty(t, get("kvtest:missing"))
+
+	// values round trip and can be overwritten
+	set("kvtest:a", []byte("first"))
+	assert.Equal(t, []byte("first"), get("kvtest:a"))
+	set("kvtest:a", []byte("second"))
+	assert.Equal(t, []byte("second"), get("kvtest:a"))
+
+	// values can hold more than a single multihash
+	large := bytes.Repeat([]byte("0123456789"), 20)
+	set("kvtest:large", large)
+	assert.Equal(t, large, get("kvtest:large"))
+	set("kvtest:large", large[:70])
+	assert.Equal(t, large[:70], get("kvtest:large"))
+
+	set("kvtest:b", []byte("b"))
+
+	lister, canList := kv.(dapp.KVLister)
+	if canList {
+		keys, err := lister.List(identity, "kvtest:")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kvtest:a", "kvtest:b", "kvtest:large"}, keys)
+
+		keys, err = lister.List(identity, "kvtest:l")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kvtest:large"}, keys)
+
+		keys, err = lister.List(identity, "kvtest:none")
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+	}
+
+	deleter, canDelete := kv.(dapp.KVDeleter)
+	if !canDelete {
+		return
+	}
+
+	_, err := deleter.Delete(identity, "kvtest:a")
+	require.NoError(t, err)
+	assert.Empty(t, get("kvtest:a"))
+
+	_, err = deleter.Delete(identity, "kvtest:large")
+	require.NoError(t, err)
+	assert.Empty(t, get("kvtest:large"))
+
+	// deleting a missing key succeeds
+	_, err = deleter.Delete(identity, "kvtest:missing")
+	assert.NoError(t, err)
+
+	if canList {
+		keys, err := lister.List(identity, "kvtest:")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kvtest:b"}, keys)
+	}
+}
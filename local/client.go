@@ -0,0 +1,169 @@
+package local
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/stellar"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/stellar/go-stellar-base/keypair"
+)
+
+// ClaimIdentity is the dapp identity for this package
+const ClaimIdentity = "GAPSFUNAXTGAECEIQZRXAXEOKAJLCYZBUZQJD7QNVE2MTL3CATFVOC26"
+
+// ClaimerName implements `MakesClaims`
+func (c *Client) ClaimerName() string { return "local" }
+
+// ClaimerIdentity implements `MakesClaims`
+func (c *Client) ClaimerIdentity() string {
+	return ClaimIdentity
+}
+
+// ClaimerClaims implements `MakesClaims`
+func (c *Client) ClaimerClaims() string { return "" }
+
+// Set implements dapp.KV
+func (c *Client) Set(identity dapp.Identity, key string, value []byte) (dapp.TX, error) {
+	p := kvPath(identity, key)
+
+	err := c.fs.MkdirAll(path.Dir(p), 0700)
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "local: failed to create kv dir")
+	}
+
+	err = afero.WriteFile(c.fs, p, value, 0600)
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "local: failed to write kv value")
+	}
+
+	return newTX(identity.PublicKey(), key, value), nil
+}
+
+// Get implements dapp.KV
+func (c *Client) Get(identity dapp.Identity, key string) ([]byte, error) {
+	value, err := afero.ReadFile(c.fs, kvPath(identity, key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "local: failed to read kv value")
+	}
+
+	return value, nil
+}
+
+// Delete implements dapp.KVDeleter
+func (c *Client) Delete(identity dapp.Identity, key string) (dapp.TX, error) {
+	err := c.fs.Remove(kvPath(identity, key))
+	if err != nil && !os.IsNotExist(err) {
+		return dapp.TX(""), errors.Wrap(err, "local: failed to delete kv value")
+	}
+
+	return newTX(identity.PublicKey(), key, nil), nil
+}
+
+// List implements dapp.KVLister
+func (c *Client) List(identity dapp.Identity, prefix string) ([]string, error) {
+	dir := path.Join("kv", identity.PublicKey())
+	names, err := afero.ReadDir(c.fs, dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "local: failed to list kv values")
+	}
+
+	var keys []string
+	for _, name := range names {
+		key, err := hex.DecodeString(name.Name())
+		if err != nil {
+			continue
+		}
+
+		if strings.HasPrefix(string(key), prefix) {
+			keys = append(keys, string(key))
+		}
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// ParseIdentity implements dapp.IdentityProvider
+func (c *Client) ParseIdentity(str string) (dapp.Identity, error) {
+	kp, err := keypair.Parse(str)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse identity")
+	}
+
+	return &stellar.Identity{KP: kp}, nil
+}
+
+// RandomIdentity implements dapp.IdentityProvider
+func (c *Client) RandomIdentity() (dapp.Identity, error) {
+	kp, err := keypair.Random()
+	if err != nil {
+		return nil, errors.Wrap(err, "local: create random keypair failed")
+	}
+
+	return &stellar.Identity{KP: kp}, nil
+}
+
+// AnnounceIdentity implements dapp.IdentityProvider
+func (c *Client) AnnounceIdentity(id dapp.Identity) (dapp.TX, error) {
+	announced, err := c.IsIdentityAnnounced(id)
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "local: identity existence check errored")
+	}
+
+	if announced {
+		return dapp.TX(""), errors.New("local: identity already announced")
+	}
+
+	err = c.fs.MkdirAll("identities", 0700)
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "local: failed to create identities dir")
+	}
+
+	err = afero.WriteFile(c.fs, identityPath(id), nil, 0600)
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "local: failed to record identity")
+	}
+
+	return newTX(id.PublicKey(), "announce", nil), nil
+}
+
+// IsIdentityAnnounced implements dapp.IdentityProvider
+func (c *Client) IsIdentityAnnounced(id dapp.Identity) (bool, error) {
+	return afero.Exists(c.fs, identityPath(id))
+}
+
+func identityPath(id dapp.Identity) string {
+	return path.Join("identities", id.PublicKey())
+}
+
+func kvPath(identity dapp.Identity, key string) string {
+	return path.Join("kv", identity.PublicKey(), hex.EncodeToString([]byte(key)))
+}
+
+// newTX returns a unique id for a local transaction
+func newTX(parts ...interface{}) dapp.TX {
+	h := sha256.New()
+	fmt.Fprint(h, time.Now().UnixNano())
+	for _, part := range parts {
+		fmt.Fprintf(h, "%q", part)
+	}
+
+	return dapp.TX(hex.EncodeToString(h.Sum(nil)))
+}
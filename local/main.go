@@ -0,0 +1,60 @@
+// Package local implements the store, kv and identity providers of the dapp
+// system on top of a local filesystem.  It is intended as a stand-in for the
+// decentralized providers while developing an application, and stores all of
+// its state beneath a single directory.
+package local
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// Client stores content, kv values and announced identities in a filesystem.
+type Client struct {
+	fs afero.Fs
+}
+
+// New creates a new local client that stores its state in `fs`.
+func New(fs afero.Fs) *Client {
+	return &Client{fs: fs}
+}
+
+// NewDir creates a new local client that stores its state in the directory
+// at `dir`, creating it if needed.
+func NewDir(dir string) (*Client, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, errors.Wrap(err, "local: failed to create state dir")
+	}
+
+	return New(afero.NewBasePathFs(afero.NewOsFs(), dir)), nil
+}
+
+// DefaultDir returns the directory in the user's cache used to store the state
+// of the application identified by `app`.
+func DefaultDir(app string) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "local: failed to find user cache dir")
+	}
+
+	return filepath.Join(cache, "dapp", "dev", app), nil
+}
+
+func init() {
+	// local:///path/to/dir stores state in the given directory, while
+	// local://path/to/dir is relative to the working directory.
+	dapp.RegisterProvider("local", func(u *url.URL) (interface{}, error) {
+		dir := u.Host + u.Path
+		if dir == "" {
+			return nil, errors.New("local: state dir required")
+		}
+
+		return NewDir(dir)
+	})
+}
@@ -0,0 +1,104 @@
+package local_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/kvtest"
+	"github.com/dappstore/go-dapp/local"
+	"github.com/dappstore/go-dapp/protocols/claim"
+	"github.com/dappstore/go-dapp/protocols/hash"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ hash.Hasher = &local.Client{}
+var _ claim.MakesClaims = &local.Client{}
+var _ dapp.Store = &local.Client{}
+var _ dapp.KV = &local.Client{}
+var _ dapp.KVDeleter = &local.Client{}
+var _ dapp.KVLister = &local.Client{}
+var _ dapp.IdentityProvider = &local.Client{}
+
+func TestClient_Store(t *testing.T) {
+	c := local.New(afero.NewMemMapFs())
+
+	dir, err := ioutil.TempDir("", "dapp-local")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "nested"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, "a"), []byte("a"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, "nested", "b"), []byte("b"), 0755))
+
+	hash, err := c.StorePath(src)
+	require.NoError(t, err)
+	assert.True(t, hash.Equals(c.HashLocalPath(src)))
+
+	// directories round trip
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, c.LoadPath(dest, hash))
+	assert.True(t, hash.Equals(c.HashLocalPath(dest)))
+	stat, err := os.Stat(filepath.Join(dest, "nested", "b"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), stat.Mode().Perm())
+
+	// existing destinations are not overwritten
+	assert.Error(t, c.LoadPath(dest, hash))
+
+	// unknown content fails to load
+	other := local.New(afero.NewMemMapFs())
+	assert.Error(t, other.LoadPath(filepath.Join(dir, "missing"), hash))
+}
+
+func TestClient_KV(t *testing.T) {
+	c := local.New(afero.NewMemMapFs())
+
+	id, err := c.RandomIdentity()
+	require.NoError(t, err)
+
+	// unset keys are empty
+	value, err := c.Get(id, "dapp:publications")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	_, err = c.Set(id, "dapp:publications", []byte("hello"))
+	require.NoError(t, err)
+
+	value, err = c.Get(id, "dapp:publications")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(value))
+
+	kvtest.TestKV(t, c, id)
+}
+
+func TestClient_Identity(t *testing.T) {
+	c := local.New(afero.NewMemMapFs())
+
+	id, err := c.RandomIdentity()
+	require.NoError(t, err)
+
+	parsed, err := c.ParseIdentity(id.PublicKey())
+	require.NoError(t, err)
+	assert.True(t, id.Equals(parsed))
+
+	announced, err := c.IsIdentityAnnounced(id)
+	require.NoError(t, err)
+	assert.False(t, announced)
+
+	_, err = c.AnnounceIdentity(id)
+	require.NoError(t, err)
+
+	announced, err = c.IsIdentityAnnounced(parsed)
+	require.NoError(t, err)
+	assert.True(t, announced)
+
+	// identities can only be announced once
+	_, err = c.AnnounceIdentity(id)
+	assert.Error(t, err)
+}
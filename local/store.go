@@ -0,0 +1,192 @@
+package local
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/jbenet/go-multihash"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// treePrefix is prepended to a serialized directory listing before hashing,
+// ensuring a directory's hash differs from that of a file with the same
+// contents as its listing.
+const treePrefix = "dapp-tree\n"
+
+// treeEntry represents a single child of a stored directory
+type treeEntry struct {
+	Name string
+	Hash string
+	Mode os.FileMode
+}
+
+// HashLocalPath implements hash.Hasher
+func (c *Client) HashLocalPath(path string) dapp.Hash {
+	hash, err := c.add(path, false)
+	if err != nil {
+		panic(errors.Wrap(err, "local-hasher: hash failed"))
+	}
+
+	return hash
+}
+
+// LoadPath implements dapp.Store
+func (c *Client) LoadPath(dir string, content dapp.Hash) error {
+	_, err := os.Stat(dir)
+	if err == nil {
+		return errors.New("local-load: destination exists")
+	}
+
+	if !os.IsNotExist(err) {
+		return errors.Wrap(err, "local: stat destination failed")
+	}
+
+	err = c.load(dir, content, 0644)
+	if err != nil {
+		return errors.Wrap(err, "local: load failed")
+	}
+
+	return nil
+}
+
+// StorePath implements dapp.Store
+func (c *Client) StorePath(path string) (dapp.Hash, error) {
+	return c.add(path, true)
+}
+
+// add hashes the file or directory at `p`, recording it in the client's
+// filesystem when `store` is true.
+func (c *Client) add(p string, store bool) (dapp.Hash, error) {
+	stat, err := os.Stat(p)
+	if err != nil {
+		return dapp.Hash{}, errors.Wrap(err, "local-add: path doesn't exist")
+	}
+
+	if !stat.IsDir() {
+		contents, err := ioutil.ReadFile(p)
+		if err != nil {
+			return dapp.Hash{}, errors.Wrap(err, "local-add: failed to read file")
+		}
+
+		return c.put("objects", contents, contents, store)
+	}
+
+	children, err := ioutil.ReadDir(p)
+	if err != nil {
+		return dapp.Hash{}, errors.Wrap(err, "local-add: failed to read dir")
+	}
+
+	entries := []treeEntry{}
+	for _, child := range children {
+		hash, err := c.add(filepath.Join(p, child.Name()), store)
+		if err != nil {
+			return dapp.Hash{}, err
+		}
+
+		entries = append(entries, treeEntry{
+			Name: child.Name(),
+			Hash: hash.String(),
+			Mode: child.Mode().Perm(),
+		})
+	}
+
+	listing, err := json.Marshal(entries)
+	if err != nil {
+		return dapp.Hash{}, errors.Wrap(err, "local-add: failed to encode dir")
+	}
+
+	return c.put("trees", append([]byte(treePrefix), listing...), listing, store)
+}
+
+// put hashes `preimage`, recording `contents` under the resulting hash in
+// `kind` when `store` is true.
+func (c *Client) put(
+	kind string,
+	preimage []byte,
+	contents []byte,
+	store bool,
+) (dapp.Hash, error) {
+	mh, err := multihash.Sum(preimage, multihash.SHA2_256, -1)
+	if err != nil {
+		return dapp.Hash{}, errors.Wrap(err, "local-add: hash failed")
+	}
+	hash := dapp.Hash{Multihash: mh}
+
+	if !store {
+		return hash, nil
+	}
+
+	err = c.fs.MkdirAll(kind, 0700)
+	if err != nil {
+		return dapp.Hash{}, errors.Wrap(err, "local-add: failed to create object dir")
+	}
+
+	err = afero.WriteFile(c.fs, path.Join(kind, hash.String()), contents, 0600)
+	if err != nil {
+		return dapp.Hash{}, errors.Wrap(err, "local-add: failed to write object")
+	}
+
+	return hash, nil
+}
+
+// load writes the content addressed by `content` to `dest`
+func (c *Client) load(dest string, content dapp.Hash, mode os.FileMode) error {
+	listing, err := afero.ReadFile(c.fs, path.Join("trees", content.String()))
+	switch {
+	case err == nil:
+		return c.loadTree(dest, listing)
+	case !os.IsNotExist(err):
+		return errors.Wrap(err, "failed to read tree")
+	}
+
+	contents, err := afero.ReadFile(c.fs, path.Join("objects", content.String()))
+	if os.IsNotExist(err) {
+		return errors.Errorf("content not found: %s", content)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to read object")
+	}
+
+	return ioutil.WriteFile(dest, contents, mode)
+}
+
+func (c *Client) loadTree(dest string, listing []byte) error {
+	var entries []treeEntry
+	err := json.Unmarshal(listing, &entries)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode tree")
+	}
+
+	err = os.MkdirAll(dest, 0755)
+	if err != nil {
+		return errors.Wrap(err, "failed to create dir")
+	}
+
+	for _, entry := range entries {
+		if entry.Name != filepath.Base(entry.Name) || entry.Name == ".." {
+			return errors.Errorf("invalid name in tree: %q", entry.Name)
+		}
+
+		mh, err := multihash.FromB58String(entry.Hash)
+		if err != nil {
+			return errors.Wrap(err, "invalid hash in tree")
+		}
+
+		err = c.load(
+			filepath.Join(dest, entry.Name),
+			dapp.Hash{Multihash: mh},
+			entry.Mode,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
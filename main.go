@@ -2,7 +2,10 @@ package dapp
 
 import (
 	"bytes"
+	"context"
 	"flag"
+	"runtime/debug"
+	"sync"
 
 	"github.com/jbenet/go-multihash"
 )
@@ -36,6 +39,13 @@ type Identity interface {
 	Sign(input []byte) ([]byte, error)
 }
 
+// HealthChecker represents a provider that can check that the services it
+// depends upon are reachable and usable.  Providers are not required to
+// implement it.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
 // IdentityProvider provides ids, either through parsing serialized values or
 // generating random identities.
 type IdentityProvider interface {
@@ -52,6 +62,18 @@ type KV interface {
 	Get(identity Identity, key string) ([]byte, error)
 }
 
+// KVDeleter represents a KV that can delete the value at a key.  Deleting a
+// key that has no value succeeds.
+type KVDeleter interface {
+	Delete(identity Identity, key string) (TX, error)
+}
+
+// KVLister represents a KV that can list the keys that have a value for an
+// identity.  Keys are returned in sorted order.
+type KVLister interface {
+	List(identity Identity, prefix string) ([]string, error)
+}
+
 // Store represents a module that can store and load filesystems
 // addressed by their content.
 type Store interface {
@@ -67,28 +89,171 @@ type Store interface {
 // TX represents the id of a transaction
 type TX string
 
+// userKey is the context key for the identity attached by WithUser
+type userKey struct{}
+
 // CurrentUser returns the current process' identity within `app`
 func CurrentUser(app string) Identity {
+	sessionLock.RLock()
+	defer sessionLock.RUnlock()
 	return loginSessions[app]
 }
 
 // Login logs the current process into `app` as `user`, replacing any current
 // session.
 func Login(app string, user Identity) {
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
 	loginSessions[app] = user
 }
 
 // Logout logs the current process out of `app`
 func Logout(app string) {
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
 	delete(loginSessions, app)
 }
 
+// UserFromContext returns the identity attached to `ctx` by WithUser, or nil
+// if no identity is attached.
+func UserFromContext(ctx context.Context) Identity {
+	user, _ := ctx.Value(userKey{}).(Identity)
+	return user
+}
+
+// WithUser returns a copy of `ctx` that carries `user`, allowing a process that
+// serves many users to act on behalf of a single user without changing the
+// process-wide login.
+func WithUser(ctx context.Context, user Identity) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// DevMode returns true if the `-dapp.dev` flag was provided
+func DevMode() bool {
+	return *dev
+}
+
+// ExplainRequested returns true if the `-dapp.explain` flag was provided
+func ExplainRequested() bool {
+	return *explain
+}
+
+// ClaimsPath returns the path provided with the `-dapp.claims` flag, or the
+// empty string if none was provided
+func ClaimsPath() string {
+	return *claimsPath
+}
+
+// IDRequested returns true if the `-dapp.id` flag was provided
+func IDRequested() bool {
+	return *printID
+}
+
+// Quiet returns true if the `-dapp.quiet` flag was provided
+func Quiet() bool {
+	return *quiet
+}
+
+// Version returns the version of the running binary.  A version injected at
+// link time takes precedence, followed by the main module's version and vcs
+// revision as recorded by the go toolchain.
+func Version() string {
+	return buildVersion(debug.ReadBuildInfo())
+}
+
+// buildVersion returns the version of a binary built as described by `info`,
+// or the link time version alone when `ok` is false.
+func buildVersion(info *debug.BuildInfo, ok bool) string {
+	if version != "devel" {
+		return version
+	}
+
+	if !ok {
+		return version
+	}
+
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+
+	if revision == "" {
+		return version
+	}
+
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+
+	if modified == "true" {
+		revision += "-dirty"
+	}
+
+	return version + "+" + revision
+}
+
+// VersionRequested returns true if the `-dapp.version` flag was provided
+func VersionRequested() bool {
+	return *printVersion
+}
+
+// UpdateChannel returns the release channel requested with the `-dapp.update`
+// flag, or the empty string if no update was requested.
+func UpdateChannel() string {
+	return *update
+}
+
 var dev = flag.Bool(
 	"dapp.dev",
 	false,
 	"enables developer mode",
 )
 
+var identityURI = flag.String(
+	"dapp.identity",
+	"",
+	"uri of the identity provider to use, e.g. stellar+testnet://horizon-testnet.stellar.org",
+)
+
+var kvURI = flag.String(
+	"dapp.kv",
+	"",
+	"uri of the kv provider to use, e.g. mem://",
+)
+
+var storeURI = flag.String(
+	"dapp.store",
+	"",
+	"uri of the store provider to use, e.g. ipfs://127.0.0.1:5001",
+)
+
+var claimsPath = flag.String(
+	"dapp.claims",
+	"",
+	"path to write the app's claims to when it shuts down, defaults to a file in the user's cache",
+)
+
+var explain = flag.Bool(
+	"dapp.explain",
+	false,
+	"print the policies, providers and claims the app would apply and exit",
+)
+
+var quiet = flag.Bool(
+	"dapp.quiet",
+	false,
+	"suppress informational notices, such as new version notifications",
+)
+
 var printVersion = flag.Bool(
 	"dapp.version",
 	false,
@@ -101,8 +266,17 @@ var printID = flag.Bool(
 	"print the app's id and exit",
 )
 
+var update = flag.String(
+	"dapp.update",
+	"",
+	"update the binary to the latest version published on the provided channel and exit",
+)
+
+// version is the version of the running binary.  Release builds can inject it
+// at link time with `-ldflags "-X github.com/dappstore/go-dapp.version=v1.2.3"`.
 var version = "devel"
 var loginSessions map[string]Identity
+var sessionLock sync.RWMutex
 
 func init() {
 	loginSessions = map[string]Identity{}
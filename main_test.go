@@ -0,0 +1,78 @@
+package dapp
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogin_Concurrent(t *testing.T) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			app := fmt.Sprintf("app-%d", i%5)
+			user := &MockIdentity{PK: fmt.Sprintf("user-%d", i)}
+
+			Login(app, user)
+			CurrentUser(app)
+			Logout(app)
+		}(i)
+	}
+
+	wg.Wait()
+	assert.Nil(t, CurrentUser("app-0"))
+}
+
+func TestWithUser(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, UserFromContext(ctx))
+
+	alice := &MockIdentity{PK: "alice"}
+	bob := &MockIdentity{PK: "bob"}
+
+	actx := WithUser(ctx, alice)
+	bctx := WithUser(ctx, bob)
+	assert.True(t, alice.Equals(UserFromContext(actx)))
+	assert.True(t, bob.Equals(UserFromContext(bctx)))
+
+	// the process-wide login is unaffected
+	assert.Nil(t, CurrentUser("app"))
+}
+
+func TestBuildVersion(t *testing.T) {
+	defer func(v string) { version = v }(version)
+
+	devel := &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}
+	vcs := &debug.BuildInfo{
+		Main: debug.Module{Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	module := &debug.BuildInfo{Main: debug.Module{Version: "v1.0.0"}}
+
+	// without build info or a version injected at link time
+	version = "devel"
+	assert.Equal(t, "devel", buildVersion(nil, false))
+	assert.Equal(t, "devel", buildVersion(devel, true))
+	assert.Equal(t, "devel", buildVersion(&debug.BuildInfo{}, true))
+
+	// recorded by the go toolchain
+	assert.Equal(t, "v1.0.0", buildVersion(module, true))
+	assert.Equal(t, "devel+0123456789ab-dirty", buildVersion(vcs, true))
+
+	// injected at link time with -ldflags
+	version = "v1.2.3"
+	assert.Equal(t, "v1.2.3", buildVersion(nil, false))
+	assert.Equal(t, "v1.2.3", buildVersion(module, true))
+	assert.Equal(t, "v1.2.3", buildVersion(vcs, true))
+	assert.Equal(t, "v1.2.3", Version())
+}
@@ -0,0 +1,52 @@
+package mem
+
+import (
+	"github.com/dappstore/go-dapp"
+	"github.com/jbenet/go-multihash"
+	"github.com/pkg/errors"
+)
+
+// ClaimerName implements `MakesClaims`
+func (c *Client) ClaimerName() string { return "mem" }
+
+// ClaimerIdentity implements `MakesClaims`
+func (c *Client) ClaimerIdentity() string {
+	return ClaimIdentity
+}
+
+// Commit implements tx.System
+func (c *Client) Commit(
+	source dapp.Identity,
+	tx dapp.TX,
+	signers []dapp.Identity,
+) (dapp.Hash, error) {
+	preimage := []byte(source.PublicKey() + "\n" + string(tx))
+	for _, signer := range signers {
+		sig, err := signer.Sign(preimage)
+		if err != nil {
+			return dapp.Hash{}, errors.Wrap(err, "mem: signer failed to sign")
+		}
+
+		preimage = append(preimage, sig...)
+	}
+
+	mh, err := multihash.Sum(preimage, multihash.SHA2_256, -1)
+	if err != nil {
+		return dapp.Hash{}, errors.Wrap(err, "mem: hash failed")
+	}
+
+	hash := dapp.Hash{Multihash: mh}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.committed[hash.String()] = true
+
+	return hash, nil
+}
+
+// Committed implements tx.System
+func (c *Client) Committed(hash dapp.Hash) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.committed[hash.String()], nil
+}
@@ -0,0 +1,58 @@
+// Package mem provides in-memory implementations of the providers used by the
+// dapp system, suitable for tests and offline use.  Content is addressed by
+// real multihashes and identities sign with real ed25519 keys, but nothing
+// leaves the process.
+package mem
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/local"
+	"github.com/spf13/afero"
+)
+
+// ClaimIdentity is the dapp identity for this package
+const ClaimIdentity = "GDHJ5EJ637MEMO6UFENWRIQKSV4ETLQI7DCMBQ47AT5CAMAVHR4334NP"
+
+// Client implements dapp.Store, dapp.KV, dapp.IdentityProvider and tx.System
+// in memory.
+type Client struct {
+	*local.Client
+
+	lock      sync.Mutex
+	committed map[string]bool
+}
+
+var namedLock sync.Mutex
+var named = map[string]*Client{}
+
+// New creates a new, empty in-memory client
+func New() *Client {
+	return &Client{
+		Client:    local.New(afero.NewMemMapFs()),
+		committed: map[string]bool{},
+	}
+}
+
+func init() {
+	// mem:// selects a new, empty client while mem://name selects the client
+	// shared by every uri with the same name.
+	dapp.RegisterProvider("mem", func(u *url.URL) (interface{}, error) {
+		if u.Host == "" {
+			return New(), nil
+		}
+
+		namedLock.Lock()
+		defer namedLock.Unlock()
+
+		client, ok := named[u.Host]
+		if !ok {
+			client = New()
+			named[u.Host] = client
+		}
+
+		return client, nil
+	})
+}
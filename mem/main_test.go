@@ -0,0 +1,101 @@
+package mem_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/kvtest"
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/dappstore/go-dapp/protocols/claim"
+	"github.com/dappstore/go-dapp/protocols/dfs"
+	"github.com/dappstore/go-dapp/protocols/hash"
+	"github.com/dappstore/go-dapp/protocols/tx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ hash.Hasher = mem.New()
+var _ claim.MakesClaims = mem.New()
+var _ dapp.Store = mem.New()
+var _ dapp.KV = mem.New()
+var _ dapp.KVDeleter = mem.New()
+var _ dapp.KVLister = mem.New()
+var _ dapp.IdentityProvider = mem.New()
+var _ tx.System = mem.New()
+
+func TestClient_Store(t *testing.T) {
+	c := mem.New()
+
+	dir, err := ioutil.TempDir("", "dapp-mem")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hello")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello"), 0644))
+
+	// dfs works on top of the store
+	pdfs := dfs.New(c)
+	h, err := pdfs.StoreLocalPaths([]string{path})
+	require.NoError(t, err)
+
+	loaded, err := pdfs.LoadTemp(h)
+	require.NoError(t, err)
+	defer os.RemoveAll(loaded)
+
+	contents, err := ioutil.ReadFile(filepath.Join(loaded, "hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(contents))
+}
+
+func TestClient_KV(t *testing.T) {
+	c := mem.New()
+
+	id, err := c.RandomIdentity()
+	require.NoError(t, err)
+
+	kvtest.TestKV(t, c, id)
+}
+
+func TestClient_Identity(t *testing.T) {
+	c := mem.New()
+
+	id, err := c.RandomIdentity()
+	require.NoError(t, err)
+
+	sig, err := id.Sign([]byte("hello"))
+	require.NoError(t, err)
+
+	// signatures verify with the public identity
+	public, err := c.ParseIdentity(id.PublicKey())
+	require.NoError(t, err)
+	assert.NoError(t, public.Verify([]byte("hello"), sig))
+	assert.Error(t, public.Verify([]byte("goodbye"), sig))
+}
+
+func TestClient_Commit(t *testing.T) {
+	c := mem.New()
+
+	source, err := c.RandomIdentity()
+	require.NoError(t, err)
+
+	h, err := c.Commit(source, dapp.TX("tx"), []dapp.Identity{source})
+	require.NoError(t, err)
+
+	committed, err := c.Committed(h)
+	require.NoError(t, err)
+	assert.True(t, committed)
+
+	// other transactions are not committed
+	committed, err = mem.New().Committed(h)
+	require.NoError(t, err)
+	assert.False(t, committed)
+
+	// signers must be able to sign
+	public, err := c.ParseIdentity(source.PublicKey())
+	require.NoError(t, err)
+	_, err = c.Commit(source, dapp.TX("tx"), []dapp.Identity{public})
+	assert.Error(t, err)
+}
@@ -0,0 +1,53 @@
+// Package metrics instruments the providers of the dapp system.  The wrapper
+// types in this package decorate a provider, reporting the latency and outcome
+// of every operation to a Sink.  Registry is a Sink that aggregates operations
+// into counters and latency histograms, and serves them in the prometheus text
+// format.
+package metrics
+
+import (
+	"time"
+)
+
+// Default is the default registry, used by the app policy that enables
+// instrumentation when no other sink is provided.
+var Default = NewRegistry()
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency histogram
+// buckets of a registry.
+var DefaultBuckets = []float64{
+	.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30,
+}
+
+// Sink represents a type that receives the operations performed by
+// instrumented providers.  `provider` is the name of the instrumented
+// provider, `op` the name of the operation and `err` the error the operation
+// returned, if any.  Implementations must be safe for concurrent use.
+type Sink interface {
+	Observe(provider string, op string, latency time.Duration, err error)
+}
+
+// Unwrapper represents a decorated provider, such as the wrapper types of this
+// package.  Unwrap returns the provider being decorated, allowing callers to
+// reach the optional interfaces it implements.
+type Unwrapper interface {
+	Unwrap() interface{}
+}
+
+// Unwrap returns the provider ultimately decorated by `provider`, or
+// `provider` itself when it is not decorated.
+func Unwrap(provider interface{}) interface{} {
+	for {
+		w, ok := provider.(Unwrapper)
+		if !ok {
+			return provider
+		}
+
+		provider = w.Unwrap()
+	}
+}
+
+// observe reports the operation `op` that started at `start` to `sink`
+func observe(sink Sink, provider string, op string, start time.Time, err error) {
+	sink.Observe(provider, op, time.Since(start), err)
+}
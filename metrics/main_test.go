@@ -0,0 +1,131 @@
+package metrics_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/kvtest"
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/dappstore/go-dapp/metrics"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ metrics.Sink = &metrics.Registry{}
+var _ dapp.IdentityProvider = &metrics.IdentityProvider{}
+var _ dapp.KV = &metrics.KV{}
+var _ dapp.Store = &metrics.Store{}
+var _ metrics.Unwrapper = &metrics.Store{}
+
+// failingStore is a store whose every operation fails
+type failingStore struct{}
+
+func (s failingStore) StorePath(path string) (dapp.Hash, error) {
+	return dapp.Hash{}, errors.New("store failed")
+}
+
+func (s failingStore) LoadPath(path string, content dapp.Hash) error {
+	return errors.New("load failed")
+}
+
+func TestProviders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-metrics")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client := mem.New()
+	registry := metrics.NewRegistry()
+	store := metrics.NewStore(client, registry)
+	kv := metrics.NewKV(client, registry)
+	ids := metrics.NewIdentityProvider(client, registry)
+
+	path := filepath.Join(dir, "hello")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello"), 0644))
+
+	h, err := store.StorePath(path)
+	require.NoError(t, err)
+	require.NoError(t, store.LoadPath(filepath.Join(dir, "loaded"), h))
+	assert.Error(t, metrics.NewStore(failingStore{}, registry).LoadPath(dir, h))
+
+	id, err := ids.RandomIdentity()
+	require.NoError(t, err)
+	_, err = kv.Set(id, "foo", []byte("bar"))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, registry.WritePrometheus(&out))
+	text := out.String()
+
+	assert.Contains(t, text, `dapp_provider_operations_total{provider="store",op="LoadPath"} 2`)
+	assert.Contains(t, text, `dapp_provider_errors_total{provider="store",op="LoadPath"} 1`)
+	assert.Contains(t, text, `dapp_provider_operations_total{provider="store",op="StorePath"} 1`)
+	assert.Contains(t, text, `dapp_provider_operations_total{provider="kv",op="Set"} 1`)
+	assert.Contains(t, text, `dapp_provider_operations_total{provider="identity",op="RandomIdentity"} 1`)
+	assert.Contains(t, text, `dapp_provider_operation_duration_seconds_count{provider="kv",op="Set"} 1`)
+
+	// decorated providers can be unwrapped
+	assert.Equal(t, client, metrics.Unwrap(store))
+	assert.Equal(t, client, metrics.Unwrap(client))
+}
+
+// plainKV is a kv that can neither delete nor list keys
+type plainKV struct {
+	dapp.KV
+}
+
+func TestNewKV(t *testing.T) {
+	client := mem.New()
+	registry := metrics.NewRegistry()
+	id, err := client.RandomIdentity()
+	require.NoError(t, err)
+
+	// a kv that can delete and list keys can still do so when instrumented
+	kv := metrics.NewKV(client, registry)
+	assert.Implements(t, (*dapp.KVDeleter)(nil), kv)
+	assert.Implements(t, (*dapp.KVLister)(nil), kv)
+	assert.Equal(t, client, metrics.Unwrap(kv))
+	kvtest.TestKV(t, kv, id)
+
+	var out bytes.Buffer
+	require.NoError(t, registry.WritePrometheus(&out))
+	assert.Contains(t, out.String(), `dapp_provider_operations_total{provider="kv",op="Delete"}`)
+	assert.Contains(t, out.String(), `dapp_provider_operations_total{provider="kv",op="List"}`)
+
+	// but a kv that cannot does not gain the capabilities
+	kv = metrics.NewKV(plainKV{client}, registry)
+	_, canDelete := kv.(dapp.KVDeleter)
+	_, canList := kv.(dapp.KVLister)
+	assert.False(t, canDelete)
+	assert.False(t, canList)
+}
+
+func TestRegistry(t *testing.T) {
+	registry := metrics.NewRegistryBuckets([]float64{1, 0.1})
+	registry.Observe("store", "StorePath", 50*time.Millisecond, nil)
+	registry.Observe("store", "StorePath", 500*time.Millisecond, errors.New("boom"))
+	registry.Observe("store", "StorePath", 5*time.Second, nil)
+
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+
+	text := rec.Body.String()
+	for _, line := range []string{
+		`dapp_provider_operations_total{provider="store",op="StorePath"} 3`,
+		`dapp_provider_errors_total{provider="store",op="StorePath"} 1`,
+		`dapp_provider_operation_duration_seconds_bucket{provider="store",op="StorePath",le="0.1"} 1`,
+		`dapp_provider_operation_duration_seconds_bucket{provider="store",op="StorePath",le="1"} 2`,
+		`dapp_provider_operation_duration_seconds_bucket{provider="store",op="StorePath",le="+Inf"} 3`,
+		`dapp_provider_operation_duration_seconds_sum{provider="store",op="StorePath"} 5.55`,
+		`dapp_provider_operation_duration_seconds_count{provider="store",op="StorePath"} 3`,
+	} {
+		assert.Contains(t, text, line+"\n")
+	}
+}
@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/dappstore/go-dapp"
+)
+
+// IdentityProvider decorates a dapp.IdentityProvider, reporting every
+// operation to `Sink` under the provider name `Name`.
+type IdentityProvider struct {
+	dapp.IdentityProvider
+	Name string
+	Sink Sink
+}
+
+// KV decorates a dapp.KV, reporting every operation to `Sink` under the
+// provider name `Name`.  A KV does not delete or list keys; use NewKV to
+// decorate a kv that can such that the decorator can too.
+type KV struct {
+	dapp.KV
+	Name string
+	Sink Sink
+}
+
+// Store decorates a dapp.Store, reporting every operation to `Sink` under the
+// provider name `Name`.
+type Store struct {
+	dapp.Store
+	Name string
+	Sink Sink
+}
+
+// NewIdentityProvider instruments `ids`, reporting its operations to `sink` as
+// the "identity" provider.
+func NewIdentityProvider(ids dapp.IdentityProvider, sink Sink) *IdentityProvider {
+	return &IdentityProvider{IdentityProvider: ids, Name: "identity", Sink: sink}
+}
+
+// deleterKV is a KV whose decorated kv can delete keys
+type deleterKV struct{ *KV }
+
+// listerKV is a KV whose decorated kv can list keys
+type listerKV struct{ *KV }
+
+// deleterListerKV is a KV whose decorated kv can delete and list keys
+type deleterListerKV struct{ *KV }
+
+// NewKV instruments `kv`, reporting its operations to `sink` as the "kv"
+// provider.  The returned kv implements dapp.KVDeleter and dapp.KVLister
+// exactly when `kv` does.
+func NewKV(kv dapp.KV, sink Sink) dapp.KV {
+	p := &KV{KV: kv, Name: "kv", Sink: sink}
+
+	_, canDelete := kv.(dapp.KVDeleter)
+	_, canList := kv.(dapp.KVLister)
+
+	switch {
+	case canDelete && canList:
+		return deleterListerKV{p}
+	case canDelete:
+		return deleterKV{p}
+	case canList:
+		return listerKV{p}
+	default:
+		return p
+	}
+}
+
+// NewStore instruments `store`, reporting its operations to `sink` as the
+// "store" provider.
+func NewStore(store dapp.Store, sink Sink) *Store {
+	return &Store{Store: store, Name: "store", Sink: sink}
+}
+
+// ParseIdentity implements dapp.IdentityProvider
+func (p *IdentityProvider) ParseIdentity(str string) (id dapp.Identity, err error) {
+	defer func(start time.Time) {
+		observe(p.Sink, p.Name, "ParseIdentity", start, err)
+	}(time.Now())
+
+	return p.IdentityProvider.ParseIdentity(str)
+}
+
+// RandomIdentity implements dapp.IdentityProvider
+func (p *IdentityProvider) RandomIdentity() (id dapp.Identity, err error) {
+	defer func(start time.Time) {
+		observe(p.Sink, p.Name, "RandomIdentity", start, err)
+	}(time.Now())
+
+	return p.IdentityProvider.RandomIdentity()
+}
+
+// AnnounceIdentity implements dapp.IdentityProvider
+func (p *IdentityProvider) AnnounceIdentity(id dapp.Identity) (tx dapp.TX, err error) {
+	defer func(start time.Time) {
+		observe(p.Sink, p.Name, "AnnounceIdentity", start, err)
+	}(time.Now())
+
+	return p.IdentityProvider.AnnounceIdentity(id)
+}
+
+// IsIdentityAnnounced implements dapp.IdentityProvider
+func (p *IdentityProvider) IsIdentityAnnounced(id dapp.Identity) (announced bool, err error) {
+	defer func(start time.Time) {
+		observe(p.Sink, p.Name, "IsIdentityAnnounced", start, err)
+	}(time.Now())
+
+	return p.IdentityProvider.IsIdentityAnnounced(id)
+}
+
+// Unwrap implements `Unwrapper`
+func (p *IdentityProvider) Unwrap() interface{} { return p.IdentityProvider }
+
+// Set implements dapp.KV
+func (p *KV) Set(identity dapp.Identity, key string, value []byte) (tx dapp.TX, err error) {
+	defer func(start time.Time) {
+		observe(p.Sink, p.Name, "Set", start, err)
+	}(time.Now())
+
+	return p.KV.Set(identity, key, value)
+}
+
+// Get implements dapp.KV
+func (p *KV) Get(identity dapp.Identity, key string) (value []byte, err error) {
+	defer func(start time.Time) {
+		observe(p.Sink, p.Name, "Get", start, err)
+	}(time.Now())
+
+	return p.KV.Get(identity, key)
+}
+
+// delete deletes `key` using the decorated kv, which must be a
+// dapp.KVDeleter
+func (p *KV) delete(identity dapp.Identity, key string) (tx dapp.TX, err error) {
+	defer func(start time.Time) {
+		observe(p.Sink, p.Name, "Delete", start, err)
+	}(time.Now())
+
+	return p.KV.(dapp.KVDeleter).Delete(identity, key)
+}
+
+// list lists the keys with `prefix` using the decorated kv, which must be a
+// dapp.KVLister
+func (p *KV) list(identity dapp.Identity, prefix string) (keys []string, err error) {
+	defer func(start time.Time) {
+		observe(p.Sink, p.Name, "List", start, err)
+	}(time.Now())
+
+	return p.KV.(dapp.KVLister).List(identity, prefix)
+}
+
+// Delete implements dapp.KVDeleter
+func (p deleterKV) Delete(identity dapp.Identity, key string) (dapp.TX, error) {
+	return p.delete(identity, key)
+}
+
+// List implements dapp.KVLister
+func (p listerKV) List(identity dapp.Identity, prefix string) ([]string, error) {
+	return p.list(identity, prefix)
+}
+
+// Delete implements dapp.KVDeleter
+func (p deleterListerKV) Delete(identity dapp.Identity, key string) (dapp.TX, error) {
+	return p.delete(identity, key)
+}
+
+// List implements dapp.KVLister
+func (p deleterListerKV) List(identity dapp.Identity, prefix string) ([]string, error) {
+	return p.list(identity, prefix)
+}
+
+// Unwrap implements `Unwrapper`
+func (p *KV) Unwrap() interface{} { return p.KV }
+
+// StorePath implements dapp.Store
+func (p *Store) StorePath(path string) (hash dapp.Hash, err error) {
+	defer func(start time.Time) {
+		observe(p.Sink, p.Name, "StorePath", start, err)
+	}(time.Now())
+
+	return p.Store.StorePath(path)
+}
+
+// LoadPath implements dapp.Store
+func (p *Store) LoadPath(path string, content dapp.Hash) (err error) {
+	defer func(start time.Time) {
+		observe(p.Sink, p.Name, "LoadPath", start, err)
+	}(time.Now())
+
+	return p.Store.LoadPath(path, content)
+}
+
+// Unwrap implements `Unwrapper`
+func (p *Store) Unwrap() interface{} { return p.Store }
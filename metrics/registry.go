@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry is a Sink that aggregates the operations of instrumented providers
+// into per-operation counters, error counts and latency histograms.  A
+// registry is an http.Handler that serves its metrics in the prometheus text
+// exposition format.
+type Registry struct {
+	buckets []float64
+
+	lock sync.Mutex
+	ops  map[operation]*stats
+}
+
+// operation identifies a single operation of a provider
+type operation struct {
+	provider string
+	op       string
+}
+
+// stats are the aggregated observations of a single operation
+type stats struct {
+	count   uint64
+	errors  uint64
+	sum     float64
+	buckets []uint64
+}
+
+// NewRegistry creates a new registry whose histograms use DefaultBuckets
+func NewRegistry() *Registry {
+	return NewRegistryBuckets(DefaultBuckets)
+}
+
+// NewRegistryBuckets creates a new registry whose histograms use `buckets`,
+// the upper bounds of each bucket in seconds.
+func NewRegistryBuckets(buckets []float64) *Registry {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &Registry{
+		buckets: sorted,
+		ops:     map[operation]*stats{},
+	}
+}
+
+// Observe implements `Sink`
+func (r *Registry) Observe(
+	provider string,
+	op string,
+	latency time.Duration,
+	err error,
+) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := operation{provider, op}
+	s, ok := r.ops[key]
+	if !ok {
+		s = &stats{buckets: make([]uint64, len(r.buckets))}
+		r.ops[key] = s
+	}
+
+	seconds := latency.Seconds()
+	s.count++
+	s.sum += seconds
+	if err != nil {
+		s.errors++
+	}
+
+	for i, le := range r.buckets {
+		if seconds <= le {
+			s.buckets[i]++
+		}
+	}
+}
+
+// ServeHTTP implements http.Handler, serving the metrics of `r`
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WritePrometheus(w)
+}
+
+// WritePrometheus writes the metrics of `r` to `w` in the prometheus text
+// exposition format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	keys := make([]operation, 0, len(r.ops))
+	for key := range r.ops {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		return keys[i].op < keys[j].op
+	})
+
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, "# HELP dapp_provider_operations_total Operations performed by dapp providers.")
+	fmt.Fprintln(out, "# TYPE dapp_provider_operations_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(out, "dapp_provider_operations_total{%s} %d\n",
+			key.labels(), r.ops[key].count)
+	}
+
+	fmt.Fprintln(out, "# HELP dapp_provider_errors_total Operations performed by dapp providers that failed.")
+	fmt.Fprintln(out, "# TYPE dapp_provider_errors_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(out, "dapp_provider_errors_total{%s} %d\n",
+			key.labels(), r.ops[key].errors)
+	}
+
+	fmt.Fprintln(out, "# HELP dapp_provider_operation_duration_seconds Latency of operations performed by dapp providers.")
+	fmt.Fprintln(out, "# TYPE dapp_provider_operation_duration_seconds histogram")
+	for _, key := range keys {
+		s := r.ops[key]
+		labels := key.labels()
+
+		for i, le := range r.buckets {
+			fmt.Fprintf(out, "dapp_provider_operation_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, formatFloat(le), s.buckets[i])
+		}
+
+		fmt.Fprintf(out, "dapp_provider_operation_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n",
+			labels, s.count)
+		fmt.Fprintf(out, "dapp_provider_operation_duration_seconds_sum{%s} %s\n",
+			labels, formatFloat(s.sum))
+		fmt.Fprintf(out, "dapp_provider_operation_duration_seconds_count{%s} %d\n",
+			labels, s.count)
+	}
+
+	return out.Flush()
+}
+
+func (o operation) labels() string {
+	return fmt.Sprintf("provider=\"%s\",op=\"%s\"",
+		escapeLabel(o.provider), escapeLabel(o.op))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -37,6 +37,8 @@ func (c *Claims) push(path string, value interface{}) error {
 		if err != nil {
 			return errors.Wrap(err, "claim at path is not an array")
 		}
+	} else if _, ok := data.Path(path).Data().([]interface{}); !ok {
+		return errors.New("claim at path is not an array")
 	}
 
 	err = data.ArrayAppendP(value, path)
@@ -50,6 +50,13 @@ type Protocol struct {
 	claimers       []MakesClaims
 }
 
+// State represents the claims and claimers of a protocol at a point in time
+type State struct {
+	claims         interface{}
+	claimers       []MakesClaims
+	claimersLocked bool
+}
+
 // Verifier is a type that can verify claims
 type Verifier interface {
 	VerifyClaims(*Claims) error
@@ -92,7 +99,17 @@ func Make(path string, value interface{}) error {
 
 // Push pushes a claim on the default claim protocol
 func Push(path string, value interface{}) error {
-	return Default.Make(path, value)
+	return Default.Push(path, value)
+}
+
+// Restore returns the default claim protocol to the state captured in `s`
+func Restore(s *State) error {
+	return Default.Restore(s)
+}
+
+// Snapshot captures the state of the default claim protocol
+func Snapshot() *State {
+	return Default.Snapshot()
 }
 
 // Verify runs the verification process on `claims`, consulting with the members
@@ -67,10 +67,12 @@ func TestLockClaimers(t *testing.T) {
 		p.Claims.data.Path(LockerClaimersClaimPath).Data().(string),
 	)
 
-	// relocking fails
+	// relocking is a no-op
 	p = New()
 	assert.NoError(t, p.LockClaimers())
-	assert.Error(t, p.LockClaimers())
+	locked := p.Claims.data.Path(LockerClaimersClaimPath).Data()
+	assert.NoError(t, p.LockClaimers())
+	assert.Equal(t, locked, p.Claims.data.Path(LockerClaimersClaimPath).Data())
 }
 
 func TestMake(t *testing.T) {
@@ -124,6 +126,21 @@ func TestPush(t *testing.T) {
 	t.Log(data.String())
 }
 
+func TestPush_Default(t *testing.T) {
+	snapshot := Snapshot()
+	defer func() { require.NoError(t, Restore(snapshot)) }()
+
+	// the default protocol pushes claims rather than making them
+	require.NoError(t, Push("pushed", 1))
+	require.NoError(t, Push("pushed", 2))
+	assert.Equal(t, "[1,2]", Default.Claims.data.Path("pushed").String())
+
+	// and refuses to push onto a claim that is not an array
+	require.NoError(t, Make("made", 1))
+	assert.Error(t, Push("made", 2))
+	assert.Equal(t, 1, Default.Claims.data.Path("made").Data())
+}
+
 func TestWriteFile(t *testing.T) {
 	p := New()
 
@@ -151,3 +168,24 @@ func TestWriteFile(t *testing.T) {
 	assert.Equal(t, os.FileMode(0700), stat.Mode())
 
 }
+
+func TestSnapshot(t *testing.T) {
+	p := New()
+	require.NoError(t, p.Make("foo", 1))
+
+	snapshot := p.Snapshot()
+
+	require.NoError(t, p.Make("bar", 2))
+	require.NoError(t, p.AddClaimer(&MockClaimer{Name: "Mocky"}))
+	require.NoError(t, p.LockClaimers())
+
+	require.NoError(t, p.Restore(snapshot))
+	assert.Equal(t, `{"foo":1}`, p.CurrentClaims())
+
+	// claimers are unlocked and can be added again
+	assert.NoError(t, p.AddClaimer(&MockClaimer{Name: "Mocky"}))
+	assert.Len(t, p.claimers, 1)
+
+	// claims can be remade
+	assert.NoError(t, p.Make("bar", 3))
+}
@@ -7,6 +7,7 @@ package claim
 import (
 	"os"
 
+	"github.com/Jeffail/gabs"
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 )
@@ -50,13 +51,14 @@ func (p *Protocol) CurrentClaims() string {
 	return data.String()
 }
 
-// LockClaimers prevents further claimers from being added to this protocol
+// LockClaimers prevents further claimers from being added to this protocol.
+// Locking claimers that are already locked does nothing.
 func (p *Protocol) LockClaimers() error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
 	if p.claimersLocked {
-		return errors.New("protocol-claim: claimers already locked")
+		return nil
 	}
 
 	err := p.Claims.make(
@@ -85,6 +87,35 @@ func (p *Protocol) Push(path string, value interface{}) error {
 	return p.Claims.push(path, value)
 }
 
+// Restore returns `p` to the state captured in `s`.
+func (p *Protocol) Restore(s *State) error {
+	data, err := gabs.Consume(copyData(s.claims))
+	if err != nil {
+		return errors.Wrap(err, "protocol-claim: failed to restore claims")
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.Claims.data = data
+	p.claimers = append([]MakesClaims(nil), s.claimers...)
+	p.claimersLocked = s.claimersLocked
+	return nil
+}
+
+// Snapshot captures the current claims and claimers of `p`, such that they
+// can later be restored.
+func (p *Protocol) Snapshot() *State {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return &State{
+		claims:         copyData(p.Claims.data.Data()),
+		claimers:       append([]MakesClaims(nil), p.claimers...),
+		claimersLocked: p.claimersLocked,
+	}
+}
+
 // WriteFile saves the current process' claims to disk
 func (p *Protocol) WriteFile(fs afero.Fs, path string, perm os.FileMode) error {
 	p.lock.Lock()
@@ -98,3 +129,24 @@ func (p *Protocol) WriteFile(fs afero.Fs, path string, perm os.FileMode) error {
 
 	return nil
 }
+
+// copyData deep copies the objects and arrays of a claims tree, sharing the
+// claimed values themselves.
+func copyData(data interface{}) interface{} {
+	switch data := data.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(data))
+		for k, v := range data {
+			ret[k] = copyData(v)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(data))
+		for i, v := range data {
+			ret[i] = copyData(v)
+		}
+		return ret
+	default:
+		return data
+	}
+}
@@ -0,0 +1,135 @@
+package publish
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/protocols/dfs"
+	"github.com/pkg/errors"
+)
+
+// AdvisoriesPath is the path, relative to the root of a publication, of the
+// directory that contains the publisher's advisories, one json file each.
+const AdvisoriesPath = "_dapp/advisories"
+
+// AdvisoriesKey is the kv key at which a publisher records the hash of a json
+// list of advisories, allowing advisories to be issued without republishing.
+const AdvisoriesKey = "dapp:advisories"
+
+// The severities of an advisory, from least to most severe.  A binary that has
+// been revoked should not be run at all.
+const (
+	SeverityWarning  = "warning"
+	SeverityDegraded = "degraded"
+	SeverityRevoked  = "revoked"
+)
+
+// Advisory represents a vulnerability or revocation record issued by a
+// publisher against the binaries whose hashes are listed in `Hashes`.
+type Advisory struct {
+	ID       string
+	Severity string
+	Summary  string
+	Hashes   []string
+}
+
+// Affects returns true if `a` applies to the binary whose hash is `h`.
+func (a Advisory) Affects(h dapp.Hash) bool {
+	for _, affected := range a.Hashes {
+		if affected == h.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetAdvisories loads the advisories issued by `publisher`, both those
+// recorded in the kv at AdvisoriesKey and those included in `publication`.
+func (sys *Protocol) GetAdvisories(
+	publisher dapp.Identity,
+	publication dapp.Hash,
+) ([]Advisory, error) {
+	pdfs := dfs.New(sys.store)
+
+	raw, err := sys.kv.Get(publisher, AdvisoriesKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "protocol-publish: failed to get advisories hash")
+	}
+
+	var advisories []Advisory
+	if len(raw) > 0 {
+		path, err := pdfs.LoadTemp(dapp.Hash{Multihash: raw})
+		if err != nil {
+			return nil, errors.Wrap(err, "protocol-publish: failed to load advisories")
+		}
+		defer os.RemoveAll(path)
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "protocol-publish: failed to read advisories")
+		}
+
+		err = json.Unmarshal(contents, &advisories)
+		if err != nil {
+			return nil, errors.Wrap(err, "protocol-publish: failed to parse advisories")
+		}
+	}
+
+	if len(publication.Multihash) == 0 {
+		return advisories, nil
+	}
+
+	published, err := sys.loadPublishedAdvisories(pdfs, publication)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(advisories, published...), nil
+}
+
+func (sys *Protocol) loadPublishedAdvisories(
+	pdfs *dfs.Protocol,
+	publication dapp.Hash,
+) ([]Advisory, error) {
+	dir, err := pdfs.LoadTemp(publication)
+	if err != nil {
+		return nil, errors.Wrap(err, "protocol-publish: failed to load publication")
+	}
+	defer os.RemoveAll(dir)
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, AdvisoriesPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "protocol-publish: failed to list advisories")
+	}
+
+	advisories := make([]Advisory, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		name := file.Name()
+		contents, err := ioutil.ReadFile(filepath.Join(dir, AdvisoriesPath, name))
+		if err != nil {
+			return nil, errors.Wrap(err, "protocol-publish: failed to read advisory")
+		}
+
+		var advisory Advisory
+		err = json.Unmarshal(contents, &advisory)
+		if err != nil {
+			return nil, errors.Wrapf(err, "protocol-publish: failed to parse advisory %s", name)
+		}
+
+		advisories = append(advisories, advisory)
+	}
+
+	return advisories, nil
+}
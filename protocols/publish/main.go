@@ -2,15 +2,24 @@ package publish
 
 import (
 	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/protocols/claim"
 )
 
 // Protocol represents a configuration of the publish protocol
 type Protocol struct {
-	store dapp.Store
-	kv    dapp.KV
+	store  dapp.Store
+	kv     dapp.KV
+	claims *claim.Protocol
 }
 
-// New creates a new dfs protocol
+// New creates a new publish protocol that merges the claims made on the
+// default claim protocol into publications.
 func New(kv dapp.KV, store dapp.Store) *Protocol {
-	return &Protocol{kv: kv, store: store}
+	return NewWithClaims(kv, store, claim.Default)
+}
+
+// NewWithClaims creates a new publish protocol that merges the claims made on
+// `claims` into publications.
+func NewWithClaims(kv dapp.KV, store dapp.Store, claims *claim.Protocol) *Protocol {
+	return &Protocol{kv: kv, store: store, claims: claims}
 }
@@ -2,7 +2,6 @@ package publish
 
 import (
 	"github.com/dappstore/go-dapp"
-	"github.com/dappstore/go-dapp/protocols/claim"
 	"github.com/dappstore/go-dapp/protocols/dfs"
 	"github.com/pkg/errors"
 )
@@ -30,7 +29,11 @@ func (sys *Protocol) SetPublications(
 ) (tx dapp.TX, publication dapp.Hash, err error) {
 
 	pdfs := dfs.New(sys.store)
-	claims, err := pdfs.StoreString(claim.Default.Claims.String())
+	claims, err := pdfs.StoreString(sys.claims.CurrentClaims())
+	if err != nil {
+		err = errors.Wrap(err, "protocol-publish: failed to store claims")
+		return
+	}
 
 	// merge current processe's claims file into hash
 	publication, err = pdfs.MergeAtPath(contents, "_dapp/claims/publish", claims)
@@ -0,0 +1,67 @@
+package publish
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/protocols/dfs"
+	"github.com/jbenet/go-multihash"
+	"github.com/pkg/errors"
+)
+
+// ChannelsPath is the path, relative to the root of a publication, of the
+// directory that contains the release manifest for each channel.
+const ChannelsPath = "_dapp/channels"
+
+// DefaultChannel is the release channel consulted when none is specified.
+const DefaultChannel = "stable"
+
+// Release represents the binaries published on a single channel, keyed by the
+// platform (in `GOOS-GOARCH` form) they were built for.
+type Release map[string]dapp.Hash
+
+// Platform returns the release key for the running platform.
+func Platform() string {
+	return runtime.GOOS + "-" + runtime.GOARCH
+}
+
+// LoadRelease loads the release manifest for `channel` from `publication`.
+func (sys *Protocol) LoadRelease(
+	publication dapp.Hash,
+	channel string,
+) (Release, error) {
+	pdfs := dfs.New(sys.store)
+
+	dir, err := pdfs.LoadTemp(publication)
+	if err != nil {
+		return nil, errors.Wrap(err, "protocol-publish: failed to load publication")
+	}
+	defer os.RemoveAll(dir)
+
+	raw, err := ioutil.ReadFile(filepath.Join(dir, ChannelsPath, channel))
+	if err != nil {
+		return nil, errors.Wrap(err, "protocol-publish: failed to read release manifest")
+	}
+
+	var manifest map[string]string
+	err = json.Unmarshal(raw, &manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "protocol-publish: failed to parse release manifest")
+	}
+
+	release := Release{}
+	for platform, b58 := range manifest {
+		h, err := multihash.FromB58String(b58)
+		if err != nil {
+			return nil, errors.Wrap(err, "protocol-publish: invalid hash in release manifest")
+		}
+
+		release[platform] = dapp.Hash{Multihash: h}
+	}
+
+	return release, nil
+}
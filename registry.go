@@ -0,0 +1,146 @@
+package dapp
+
+import (
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ProviderFactory creates a provider from the uri that selected it.  The
+// returned value implements one or more of Store, KV and IdentityProvider.
+type ProviderFactory func(uri *url.URL) (interface{}, error)
+
+var factoryLock sync.RWMutex
+var factories = map[string]ProviderFactory{}
+
+// RegisterProvider makes a provider available under the uri scheme `scheme`.
+// It is intended to be called from the init function of packages that
+// implement providers, and panics if called twice with the same scheme or
+// with a nil factory.
+func RegisterProvider(scheme string, factory ProviderFactory) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+
+	if factory == nil {
+		panic("dapp: RegisterProvider factory is nil")
+	}
+
+	if _, dup := factories[scheme]; dup {
+		panic("dapp: RegisterProvider called twice for scheme " + scheme)
+	}
+
+	factories[scheme] = factory
+}
+
+// ProviderSchemes returns a sorted list of the registered provider schemes
+func ProviderSchemes() []string {
+	factoryLock.RLock()
+	defer factoryLock.RUnlock()
+
+	var ret []string
+	for scheme := range factories {
+		ret = append(ret, scheme)
+	}
+
+	sort.Strings(ret)
+	return ret
+}
+
+// OpenProvider creates the provider selected by `uri`, such as
+// `ipfs://127.0.0.1:5001` or `mem://`, using the factory registered for the
+// uri's scheme.
+func OpenProvider(uri string) (interface{}, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, errors.Wrap(err, "dapp: invalid provider uri")
+	}
+
+	factoryLock.RLock()
+	factory, ok := factories[u.Scheme]
+	factoryLock.RUnlock()
+
+	if !ok {
+		return nil, errors.Errorf("dapp: unknown provider scheme %q (forgotten import?)", u.Scheme)
+	}
+
+	provider, err := factory(u)
+	if err != nil {
+		return nil, errors.Wrapf(err, "dapp: failed to open %s provider", u.Scheme)
+	}
+
+	return provider, nil
+}
+
+// OpenIdentityProvider opens the provider at `uri` as an IdentityProvider
+func OpenIdentityProvider(uri string) (IdentityProvider, error) {
+	provider, err := OpenProvider(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, ok := provider.(IdentityProvider)
+	if !ok {
+		return nil, errors.Errorf("dapp: %s is not an identity provider", uri)
+	}
+
+	return ids, nil
+}
+
+// OpenKV opens the provider at `uri` as a KV
+func OpenKV(uri string) (KV, error) {
+	provider, err := OpenProvider(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	kv, ok := provider.(KV)
+	if !ok {
+		return nil, errors.Errorf("dapp: %s is not a kv", uri)
+	}
+
+	return kv, nil
+}
+
+// OpenStore opens the provider at `uri` as a Store
+func OpenStore(uri string) (Store, error) {
+	provider, err := OpenProvider(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	store, ok := provider.(Store)
+	if !ok {
+		return nil, errors.Errorf("dapp: %s is not a store", uri)
+	}
+
+	return store, nil
+}
+
+// IdentityURI returns the identity provider uri selected with the
+// `-dapp.identity` flag or the DAPP_IDENTITY environment variable.
+func IdentityURI() string {
+	return flagOrEnv(*identityURI, "DAPP_IDENTITY")
+}
+
+// KVURI returns the kv uri selected with the `-dapp.kv` flag or the DAPP_KV
+// environment variable.
+func KVURI() string {
+	return flagOrEnv(*kvURI, "DAPP_KV")
+}
+
+// StoreURI returns the store uri selected with the `-dapp.store` flag or the
+// DAPP_STORE environment variable.
+func StoreURI() string {
+	return flagOrEnv(*storeURI, "DAPP_STORE")
+}
+
+func flagOrEnv(value string, env string) string {
+	if value != "" {
+		return value
+	}
+
+	return os.Getenv(env)
+}
@@ -0,0 +1,58 @@
+package dapp
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unregisterProvider removes the factory registered under `scheme`, such that
+// tests registering providers can be run repeatedly.
+func unregisterProvider(scheme string) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+
+	delete(factories, scheme)
+}
+
+func TestRegisterProvider(t *testing.T) {
+	defer unregisterProvider("mock-ids")
+	RegisterProvider("mock-ids", func(u *url.URL) (interface{}, error) {
+		if u.Host == "broken" {
+			return nil, errors.New("broken")
+		}
+
+		return &MockIdentityProvider{}, nil
+	})
+
+	assert.Contains(t, ProviderSchemes(), "mock-ids")
+
+	// registering twice panics
+	assert.Panics(t, func() {
+		RegisterProvider("mock-ids", func(*url.URL) (interface{}, error) {
+			return nil, nil
+		})
+	})
+
+	ids, err := OpenIdentityProvider("mock-ids://")
+	require.NoError(t, err)
+	assert.IsType(t, &MockIdentityProvider{}, ids)
+
+	// the provider must implement the requested interface
+	_, err = OpenKV("mock-ids://")
+	assert.Error(t, err)
+
+	_, err = OpenStore("mock-ids://")
+	assert.Error(t, err)
+
+	// factory errors are returned
+	_, err = OpenProvider("mock-ids://broken")
+	assert.Error(t, err)
+
+	// unknown schemes fail
+	_, err = OpenProvider("floppy://a")
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+// Package session persists the identities logged into dapp applications
+// between runs.  Each app's session is stored in its own file, encrypted with a
+// key derived from a passphrase, and may expire after a fixed duration.
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Exportable represents an identity that can be serialized, including its
+// secret key, into a string that its identity provider can parse back into the
+// identity.
+type Exportable interface {
+	Export() (string, error)
+}
+
+// Store saves sessions as encrypted files in a directory.
+type Store struct {
+	// Dir is the directory in which session files are written
+	Dir string
+
+	// Passphrase is used to derive the key that encrypts each session
+	Passphrase []byte
+
+	// TTL is the duration after which a saved session expires.  Sessions never
+	// expire when TTL is zero.
+	TTL time.Duration
+}
+
+// New creates a new session store that writes to `dir`, encrypting sessions
+// with `passphrase`.
+func New(dir string, passphrase []byte, ttl time.Duration) *Store {
+	return &Store{Dir: dir, Passphrase: passphrase, TTL: ttl}
+}
+
+// DefaultDir returns the directory in the user's config dir used to store
+// sessions.
+func DefaultDir() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "session: failed to find user config dir")
+	}
+
+	return filepath.Join(config, "dapp", "sessions"), nil
+}
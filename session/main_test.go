@@ -0,0 +1,90 @@
+package session_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dappstore/go-dapp/mem"
+	"github.com/dappstore/go-dapp/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const app = "GDGIXJPUTJIYHHJ2TYWO2HJMFNT7M767ZB33SFGTD77JUE3YZ6YZBUD4"
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-session")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ids := mem.New()
+	user, err := ids.RandomIdentity()
+	require.NoError(t, err)
+
+	s := session.New(dir, []byte("hunter2"), 0)
+
+	// no session
+	loaded, err := s.Load(app, ids)
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	// round trip
+	require.NoError(t, s.Save(app, user))
+	loaded, err = s.Load(app, ids)
+	require.NoError(t, err)
+	if assert.NotNil(t, loaded) {
+		assert.True(t, user.Equals(loaded))
+		_, err = loaded.Sign([]byte("hello"))
+		assert.NoError(t, err)
+	}
+
+	// the secret is not stored in the clear
+	raw, err := ioutil.ReadFile(filepath.Join(dir, app+".session"))
+	require.NoError(t, err)
+	seed, err := user.(session.Exportable).Export()
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), seed)
+
+	// wrong passphrase
+	_, err = session.New(dir, []byte("wrong"), 0).Load(app, ids)
+	assert.Error(t, err)
+
+	// delete
+	require.NoError(t, s.Delete(app))
+	loaded, err = s.Load(app, ids)
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+	assert.NoError(t, s.Delete(app))
+
+	// public identities cannot be saved
+	public, err := ids.ParseIdentity(user.PublicKey())
+	require.NoError(t, err)
+	assert.Error(t, s.Save(app, public))
+
+	// invalid app ids
+	assert.Error(t, s.Save("../escape", user))
+}
+
+func TestStore_Expiry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dapp-session")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ids := mem.New()
+	user, err := ids.RandomIdentity()
+	require.NoError(t, err)
+
+	s := session.New(dir, []byte("hunter2"), time.Millisecond)
+	require.NoError(t, s.Save(app, user))
+	time.Sleep(5 * time.Millisecond)
+
+	loaded, err := s.Load(app, ids)
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	_, err = os.Stat(filepath.Join(dir, app+".session"))
+	assert.True(t, os.IsNotExist(err))
+}
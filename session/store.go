@@ -0,0 +1,221 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// envelope is the on-disk, encrypted form of a session
+type envelope struct {
+	Salt  []byte
+	Nonce []byte
+	Box   []byte
+}
+
+// contents is the plaintext of a session
+type contents struct {
+	Identity string
+	Expires  time.Time
+}
+
+// Delete wipes and removes the session saved for `app`, if any.
+func (s *Store) Delete(app string) error {
+	path, err := s.path(app)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "session: failed to open session for wiping")
+	}
+
+	stat, err := f.Stat()
+	if err == nil {
+		_, err = f.Write(make([]byte, stat.Size()))
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	f.Close()
+	if err != nil {
+		return errors.Wrap(err, "session: failed to wipe session")
+	}
+
+	err = os.Remove(path)
+	if err != nil {
+		return errors.Wrap(err, "session: failed to remove session")
+	}
+
+	return nil
+}
+
+// Load loads the identity saved for `app`, parsing it with `ids`.  A nil
+// identity is returned when no session is saved or the saved session has
+// expired, in which case it is deleted.
+func (s *Store) Load(app string, ids dapp.IdentityProvider) (dapp.Identity, error) {
+	path, err := s.path(app)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "session: failed to read session")
+	}
+
+	var env envelope
+	err = json.Unmarshal(raw, &env)
+	if err != nil || len(env.Nonce) != 24 {
+		return nil, errors.New("session: malformed session file")
+	}
+
+	key, err := s.key(env.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], env.Nonce)
+	plain, ok := secretbox.Open(nil, env.Box, &nonce, key)
+	if !ok {
+		return nil, errors.New("session: failed to decrypt session")
+	}
+
+	var c contents
+	err = json.Unmarshal(plain, &c)
+	if err != nil {
+		return nil, errors.Wrap(err, "session: failed to decode session")
+	}
+
+	if !c.Expires.IsZero() && time.Now().After(c.Expires) {
+		return nil, s.Delete(app)
+	}
+
+	id, err := ids.ParseIdentity(c.Identity)
+	if err != nil {
+		return nil, errors.Wrap(err, "session: failed to parse identity")
+	}
+
+	return id, nil
+}
+
+// Save saves `user` as the identity logged into `app`, replacing any existing
+// session.
+func (s *Store) Save(app string, user dapp.Identity) error {
+	path, err := s.path(app)
+	if err != nil {
+		return err
+	}
+
+	exportable, ok := user.(Exportable)
+	if !ok {
+		return errors.New("session: identity cannot be exported")
+	}
+
+	var c contents
+	c.Identity, err = exportable.Export()
+	if err != nil {
+		return errors.Wrap(err, "session: failed to export identity")
+	}
+
+	if s.TTL != 0 {
+		c.Expires = time.Now().Add(s.TTL)
+	}
+
+	plain, err := json.Marshal(c)
+	if err != nil {
+		return errors.Wrap(err, "session: failed to encode session")
+	}
+
+	var env envelope
+	var nonce [24]byte
+	env.Salt = make([]byte, 16)
+
+	_, err = rand.Read(env.Salt)
+	if err == nil {
+		_, err = rand.Read(nonce[:])
+	}
+	if err != nil {
+		return errors.Wrap(err, "session: failed to read random bytes")
+	}
+
+	key, err := s.key(env.Salt)
+	if err != nil {
+		return err
+	}
+
+	env.Nonce = nonce[:]
+	env.Box = secretbox.Seal(nil, plain, &nonce, key)
+
+	raw, err := json.Marshal(env)
+	if err != nil {
+		return errors.Wrap(err, "session: failed to encode session")
+	}
+
+	err = os.MkdirAll(s.Dir, 0700)
+	if err != nil {
+		return errors.Wrap(err, "session: failed to create session dir")
+	}
+
+	tmp, err := ioutil.TempFile(s.Dir, ".session")
+	if err != nil {
+		return errors.Wrap(err, "session: failed to create session file")
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(raw)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "session: failed to write session")
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return errors.Wrap(err, "session: failed to save session")
+	}
+
+	return nil
+}
+
+// key derives the encryption key for a session from the store's passphrase
+// and `salt`.
+func (s *Store) key(salt []byte) (*[32]byte, error) {
+	if len(s.Passphrase) == 0 {
+		return nil, errors.New("session: no passphrase configured")
+	}
+
+	derived, err := scrypt.Key(s.Passphrase, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "session: failed to derive key")
+	}
+
+	var key [32]byte
+	copy(key[:], derived)
+	return &key, nil
+}
+
+func (s *Store) path(app string) (string, error) {
+	if app == "" || app != filepath.Base(app) || app == ".." {
+		return "", errors.Errorf("session: invalid app id %q", app)
+	}
+
+	return filepath.Join(s.Dir, app+".session"), nil
+}
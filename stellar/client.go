@@ -1,10 +1,13 @@
 package stellar
 
 import (
+	"context"
+
 	"github.com/dappstore/go-dapp"
 	"github.com/pkg/errors"
 	"github.com/stellar/go-stellar-base/build"
 	"github.com/stellar/go-stellar-base/keypair"
+	"github.com/stellar/go-stellar-base/xdr"
 )
 
 // ClaimIdentity is the dapp identity for this package
@@ -21,46 +24,90 @@ func (c *Client) ClaimerIdentity() string {
 // ClaimerClaims implements `MakesClaims`
 func (c *Client) ClaimerClaims() string { return "" }
 
-// Set implements kv.Kv
+// Set implements kv.Kv.  Values longer than MaxDataLength are split across
+// numbered data entries, written in a single transaction.  Empty values, nil
+// included, cannot be set; use Delete to remove a value.
 func (c *Client) Set(identity dapp.Identity, key string, value []byte) (dapp.TX, error) {
+	ctx, cancel := c.context()
+	defer cancel()
 
 	sid := identity.(*Identity)
 
-	full, ok := sid.KP.(*keypair.Full)
-	if !ok {
-		return dapp.TX(""), errors.New("stellar: don't know secret key for identity")
+	existing, err := LoadAccountData(ctx, c.Client, sid.Address())
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "stellar: load account failed")
+	}
+
+	ops, err := planSet(key, value, existing)
+	if err != nil {
+		return dapp.TX(""), err
+	}
+
+	var muts []build.TransactionMutator
+	for _, op := range ops {
+		muts = append(muts, op.mutator())
 	}
 
-	tx := build.Transaction(
-		build.SourceAccount{AddressOrSeed: sid.PublicKey()},
-		build.AutoSequence{SequenceProvider: c.Client},
-		build.SetData("dapp:publications", value),
-	)
+	return c.submit(ctx, sid, muts...)
+}
 
-	txe := tx.Sign(full.Seed())
+// Get implements kv.Kv
+func (c *Client) Get(identity dapp.Identity, key string) ([]byte, error) {
+	ctx, cancel := c.context()
+	defer cancel()
 
-	xdrs, err := txe.Base64()
+	sid := identity.(*Identity)
+	data, err := LoadAccountData(ctx, c.Client, sid.Address())
 	if err != nil {
-		return dapp.TX(""), errors.Wrap(err, "stellar: failed to craft transaction")
+		return nil, errors.Wrap(err, "stellar: load account failed")
 	}
 
-	result, err := c.Client.SubmitTransaction(xdrs)
+	return joinValue(key, data)
+}
+
+// Delete implements dapp.KVDeleter by clearing the data entries that hold the
+// value at `key`.
+func (c *Client) Delete(identity dapp.Identity, key string) (dapp.TX, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	sid := identity.(*Identity)
+
+	existing, err := LoadAccountData(ctx, c.Client, sid.Address())
 	if err != nil {
-		return dapp.TX(""), errors.Wrap(err, "stellar: transaction failed")
+		return dapp.TX(""), errors.Wrap(err, "stellar: load account failed")
+	}
+
+	ops, err := planDelete(key, existing)
+	if err != nil {
+		return dapp.TX(""), err
 	}
 
-	return dapp.TX(result.Hash), nil
+	// there is nothing to clear, and a transaction needs an operation
+	if len(ops) == 0 {
+		return dapp.TX(""), nil
+	}
+
+	var muts []build.TransactionMutator
+	for _, op := range ops {
+		muts = append(muts, op.mutator())
+	}
+
+	return c.submit(ctx, sid, muts...)
 }
 
-// Get implements kv.Kv
-func (c *Client) Get(identity dapp.Identity, key string) ([]byte, error) {
+// List implements dapp.KVLister
+func (c *Client) List(identity dapp.Identity, prefix string) ([]string, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
 	sid := identity.(*Identity)
-	data, err := LoadAccountData(c.Client, sid.Address())
+	data, err := LoadAccountData(ctx, c.Client, sid.Address())
 	if err != nil {
 		return nil, errors.Wrap(err, "stellar: load account failed")
 	}
 
-	return data[key], nil
+	return listKeys(data, prefix), nil
 }
 
 // ParseIdentity implements dapp.IdentityProvider
@@ -85,8 +132,11 @@ func (c *Client) RandomIdentity() (dapp.Identity, error) {
 
 // AnnounceIdentity implements dapp.IdentityProvider
 func (c *Client) AnnounceIdentity(id dapp.Identity) (dapp.TX, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
 	sid := id.(*Identity)
-	txHash, err := FundAccount(c.Client, sid.Address())
+	txHash, err := FundAccount(ctx, c.Client, sid.Address())
 	if err != nil {
 		return dapp.TX(""), errors.Wrap(err, "stellar: funding account failed")
 	}
@@ -97,6 +147,77 @@ func (c *Client) AnnounceIdentity(id dapp.Identity) (dapp.TX, error) {
 
 // IsIdentityAnnounced implements dapp.IdentityProvider
 func (c *Client) IsIdentityAnnounced(id dapp.Identity) (bool, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
 	sid := id.(*Identity)
-	return AccountExists(c.Client, sid.Address())
+	return AccountExists(ctx, c.Client, sid.Address())
+}
+
+// context returns the context of a kv or identity operation, whose interfaces
+// take none, bounded by the client's timeout.
+func (c *Client) context() (context.Context, context.CancelFunc) {
+	if c.timeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+// transaction builds a transaction from the account of `source` that performs
+// `ops`, for the client's network and paying its base fee.  The sequence
+// number of the transaction follows that of the source account, loaded from
+// horizon.
+func (c *Client) transaction(
+	ctx context.Context,
+	source *Identity,
+	ops ...build.TransactionMutator,
+) (*build.TransactionBuilder, error) {
+	seq, err := LoadSequence(ctx, c.Client, source.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	muts := append([]build.TransactionMutator{
+		build.SourceAccount{AddressOrSeed: source.PublicKey()},
+		build.Sequence{Sequence: seq + 1},
+		build.Network{Passphrase: c.NetworkPassphrase()},
+	}, ops...)
+
+	tx := build.Transaction(muts...)
+	if c.baseFee != 0 && tx.Err == nil {
+		tx.TX.Fee = xdr.Uint32(c.baseFee * uint64(len(tx.TX.Operations)))
+	}
+
+	return tx, nil
+}
+
+// submit builds a transaction from the account of `signer` that performs
+// `ops`, signs it using the secret key of `signer` and submits it to horizon.
+func (c *Client) submit(
+	ctx context.Context,
+	signer *Identity,
+	ops ...build.TransactionMutator,
+) (dapp.TX, error) {
+	full, ok := signer.KP.(*keypair.Full)
+	if !ok {
+		return dapp.TX(""), errors.New("stellar: don't know secret key for identity")
+	}
+
+	tx, err := c.transaction(ctx, signer, ops...)
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "stellar: failed to load sequence number")
+	}
+
+	xdrs, err := tx.Sign(full.Seed()).Base64()
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "stellar: failed to craft transaction")
+	}
+
+	hash, err := SubmitTransaction(ctx, c.Client, xdrs)
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "stellar: transaction failed")
+	}
+
+	return dapp.TX(hash), nil
 }
@@ -0,0 +1,151 @@
+package stellar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/stellar/go-stellar-base/horizon"
+)
+
+// The errors that failed horizon requests can be matched against using
+// errors.Is
+var (
+	// ErrNotFound is matched by requests for a resource horizon does not know
+	ErrNotFound = errors.New("stellar: resource not found")
+
+	// ErrAccountNotFound is returned when loading an account that does not
+	// exist, such as that of an identity that has not been announced.
+	ErrAccountNotFound = errors.New("stellar: account not found")
+
+	// ErrRateLimited is matched by requests horizon rejected because the rate
+	// limit was exceeded, even after retrying.
+	ErrRateLimited = errors.New("stellar: horizon rate limit exceeded")
+
+	// ErrBadSequence is matched by a TxFailedError for a transaction whose
+	// sequence number is not the next of its source account.
+	ErrBadSequence = errors.New("stellar: bad sequence number")
+)
+
+// problemTransactionFailed is the type of the problem horizon responds with
+// when a submitted transaction fails.  Horizon may report it in url form, as
+// in "https://stellar.org/horizon-errors/transaction_failed".
+const problemTransactionFailed = "transaction_failed"
+
+// Error is a failed horizon request, described by the problem document horizon
+// responded with.  Use errors.Is to match it against ErrNotFound and
+// ErrRateLimited.
+type Error struct {
+	Problem horizon.Problem
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("horizon: %s (%d)", e.Problem.Title, e.Problem.Status)
+	if e.Problem.Detail != "" {
+		msg += ": " + e.Problem.Detail
+	}
+
+	return msg
+}
+
+// Is reports whether `e` matches the sentinel error `target`
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Problem.Status == http.StatusNotFound
+	case ErrRateLimited:
+		return e.Problem.Status == http.StatusTooManyRequests
+	default:
+		return false
+	}
+}
+
+// TxFailedError is a transaction that horizon accepted but that failed to
+// apply, carrying the result codes of the transaction and of each of its
+// operations.  Use errors.Is to match it against ErrBadSequence.
+type TxFailedError struct {
+	Problem         horizon.Problem
+	TransactionCode string
+	OperationCodes  []string
+	ResultXDR       string
+}
+
+// Error implements error
+func (e *TxFailedError) Error() string {
+	msg := "horizon: transaction result " + e.TransactionCode
+	if len(e.OperationCodes) > 0 {
+		msg += " (" + strings.Join(e.OperationCodes, ", ") + ")"
+	}
+
+	return msg
+}
+
+// Is reports whether `e` matches the sentinel error `target`
+func (e *TxFailedError) Is(target error) bool {
+	return target == ErrBadSequence && e.TransactionCode == "tx_bad_seq"
+}
+
+// problemError returns the typed error that describes `p`
+func problemError(p horizon.Problem) error {
+	if problemType(p) != problemTransactionFailed {
+		return &Error{Problem: p}
+	}
+
+	ret := &TxFailedError{Problem: p}
+
+	var codes struct {
+		Transaction string   `json:"transaction"`
+		Operations  []string `json:"operations"`
+	}
+
+	// horizon's problems are best effort; a transaction failure is reported
+	// without result codes rather than not at all.
+	if raw, ok := p.Extras["result_codes"]; ok {
+		if json.Unmarshal(raw, &codes) == nil {
+			ret.TransactionCode = codes.Transaction
+			ret.OperationCodes = codes.Operations
+		}
+	}
+
+	if raw, ok := p.Extras["result_xdr"]; ok {
+		json.Unmarshal(raw, &ret.ResultXDR)
+	}
+
+	return ret
+}
+
+// problemType returns the type of `p` without the url it may be prefixed with
+func problemType(p horizon.Problem) string {
+	return p.Type[strings.LastIndex(p.Type, "/")+1:]
+}
+
+// decodeProblem returns the typed error that describes the failed horizon
+// response `resp`, decoding its problem document.  Responses that are not
+// problem documents are described by their status.
+func decodeProblem(resp *http.Response) error {
+	p := horizon.Problem{
+		Status: resp.StatusCode,
+		Title:  http.StatusText(resp.StatusCode),
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err == nil && json.Unmarshal(body, &p) == nil && p.Status == 0 {
+		p.Status = resp.StatusCode
+	}
+
+	return problemError(p)
+}
+
+// accountError returns ErrAccountNotFound when `err` reports that the account
+// `aid` does not exist, and `err` otherwise.
+func accountError(err error, aid string) error {
+	if errors.Is(err, ErrNotFound) {
+		return errors.Wrapf(ErrAccountNotFound, "account %s", aid)
+	}
+
+	return err
+}
@@ -0,0 +1,125 @@
+package stellar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stellar/go-stellar-base/horizon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemErrors(t *testing.T) {
+	defer func(retries int) { MaxRetries = retries }(MaxRetries)
+	MaxRetries = 0
+
+	status := http.StatusNotFound
+	body := `{
+		"type": "not_found",
+		"title": "Resource Missing",
+		"status": 404,
+		"detail": "The resource at the url requested was not found."
+	}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	h := &horizon.Client{URL: srv.URL, Client: http.DefaultClient}
+	var dest struct{}
+
+	// missing resources
+	err := decodeGet(context.Background(), h, srv.URL+"/ledgers/0", &dest)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.False(t, errors.Is(err, ErrRateLimited))
+
+	var herr *Error
+	require.True(t, errors.As(err, &herr))
+	assert.Equal(t, "not_found", herr.Problem.Type)
+	assert.Contains(t, err.Error(), "Resource Missing")
+
+	// missing accounts
+	_, err = LoadAccountData(context.Background(), h, "GABC")
+	assert.True(t, errors.Is(err, ErrAccountNotFound))
+
+	// rate limiting
+	status = http.StatusTooManyRequests
+	body = `{"type": "rate_limit_exceeded", "title": "Rate Limit Exceeded", "status": 429}`
+	err = decodeGet(context.Background(), h, srv.URL, &dest)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	_, err = LoadAccountData(context.Background(), h, "GABC")
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.False(t, errors.Is(err, ErrAccountNotFound))
+
+	// responses that aren't problems are described by their status
+	status = http.StatusBadGateway
+	body = `<html>bad gateway</html>`
+	err = decodeGet(context.Background(), h, srv.URL, &dest)
+	require.True(t, errors.As(err, &herr))
+	assert.Equal(t, http.StatusBadGateway, herr.Problem.Status)
+	assert.Equal(t, "Bad Gateway", herr.Problem.Title)
+}
+
+func TestTxFailedError(t *testing.T) {
+	body := `{
+		"type": "https://stellar.org/horizon-errors/transaction_failed",
+		"title": "Transaction Failed",
+		"status": 400,
+		"extras": {
+			"result_codes": {"transaction":"tx_failed","operations":["op_success","op_underfunded"]},
+			"result_xdr": "AAAAAAAAAGT/////AAAAAQAAAAAAAAAB/////gAAAAA="
+		}
+	}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	h := &horizon.Client{URL: srv.URL, Client: http.DefaultClient}
+
+	// failed submissions are typed, however they are wrapped
+	_, err := SubmitTransaction(context.Background(), h, "AAAA")
+	err = errors.Wrap(err, "stellar: transaction failed")
+
+	var txerr *TxFailedError
+	require.True(t, errors.As(err, &txerr))
+	assert.Equal(t, "tx_failed", txerr.TransactionCode)
+	assert.Equal(t, []string{"op_success", "op_underfunded"}, txerr.OperationCodes)
+	assert.Equal(t, "AAAAAAAAAGT/////AAAAAQAAAAAAAAAB/////gAAAAA=", txerr.ResultXDR)
+	assert.Contains(t, err.Error(), "op_underfunded")
+	assert.False(t, errors.Is(err, ErrBadSequence))
+
+	p := horizon.Problem{
+		Type:   "transaction_failed",
+		Title:  "Transaction Failed",
+		Status: 400,
+		Extras: map[string]json.RawMessage{
+			"result_codes": json.RawMessage(`{"transaction":"tx_bad_seq"}`),
+		},
+	}
+	err = errors.WithStack(problemError(p))
+	assert.True(t, errors.Is(err, ErrBadSequence))
+	assert.False(t, errors.Is(err, ErrAccountNotFound))
+
+	// problem types may be urls
+	p.Type = "https://stellar.org/horizon-errors/transaction_failed"
+	err = problemError(p)
+	require.True(t, errors.As(err, &txerr))
+	assert.Equal(t, "tx_bad_seq", txerr.TransactionCode)
+	assert.True(t, errors.Is(err, ErrBadSequence))
+
+	// other problems are not transaction failures
+	p.Type = "https://stellar.org/horizon-errors/bad_request"
+	assert.False(t, errors.As(problemError(p), &txerr))
+}
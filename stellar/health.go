@@ -0,0 +1,43 @@
+package stellar
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CheckHealth implements dapp.HealthChecker by loading the root of the horizon
+// server and checking that it serves the network that transactions are signed
+// for.
+func (c *Client) CheckHealth(ctx context.Context) error {
+	resp, err := get(ctx, c.Client, strings.TrimRight(c.URL, "/")+"/")
+	if err != nil {
+		return errors.Wrap(err, "stellar: horizon unreachable")
+	}
+	defer discard(resp)
+
+	if !(resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return errors.Wrap(decodeProblem(resp), "stellar: horizon unhealthy")
+	}
+
+	var root struct {
+		NetworkPassphrase string `json:"network_passphrase"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&root)
+	if err != nil {
+		return errors.Wrap(err, "stellar: failed to decode horizon root")
+	}
+
+	expected := c.NetworkPassphrase()
+	if root.NetworkPassphrase != expected {
+		return errors.Errorf(
+			"stellar: horizon serves %q, expected %q",
+			root.NetworkPassphrase, expected,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package stellar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/go-stellar-base/build"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_CheckHealth(t *testing.T) {
+	defer func(backoff time.Duration) { RetryBackoff = backoff }(RetryBackoff)
+	RetryBackoff = time.Millisecond
+
+	passphrase := build.DefaultNetwork.Passphrase
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprintf(w, `{"network_passphrase":%q}`, passphrase)
+	}))
+	defer srv.Close()
+
+	c := NewURL(srv.URL)
+	assert.NoError(t, c.CheckHealth(context.Background()))
+
+	// horizon serving another network is unhealthy
+	passphrase = build.PublicNetwork.Passphrase
+	assert.Error(t, c.CheckHealth(context.Background()))
+
+	// unreachable horizon is unhealthy, once connecting has been retried
+	srv.Close()
+	assert.Error(t, c.CheckHealth(context.Background()))
+}
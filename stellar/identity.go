@@ -2,6 +2,8 @@ package stellar
 
 import (
 	"github.com/dappstore/go-dapp"
+	"github.com/pkg/errors"
+	"github.com/stellar/go-stellar-base/keypair"
 )
 
 // Equals implements dapp.Identity
@@ -23,3 +25,14 @@ func (i *Identity) PublicKey() string {
 func (i *Identity) String() string {
 	return i.KP.Address()
 }
+
+// Export returns the secret seed of the identity, which can be parsed back into
+// the identity using ParseIdentity.
+func (i *Identity) Export() (string, error) {
+	full, ok := i.KP.(*keypair.Full)
+	if !ok {
+		return "", errors.New("stellar: don't know secret key for identity")
+	}
+
+	return full.Seed(), nil
+}
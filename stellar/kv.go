@@ -0,0 +1,233 @@
+package stellar
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/stellar/go-stellar-base/build"
+)
+
+// MaxDataLength is the maximum length of the name and of the value of a single
+// stellar data entry.
+const MaxDataLength = 64
+
+// MaxValueChunks is the maximum number of data entries a single kv value can
+// be split across, such that a value and the clearing of a previous value fit
+// within a single transaction.
+const MaxValueChunks = 48
+
+// chunkHeader prefixes the value of the data entry at a key whose value is
+// split across numbered data entries.  It is followed by the number of chunks
+// and the length of the value, as in "3:150".
+const chunkHeader = "\x00dapp-chunks:"
+
+// dataOp represents a single manage_data operation; a nil value clears the
+// data entry at `name`.
+type dataOp struct {
+	name  string
+	value []byte
+}
+
+// mutator returns the transaction mutator that performs `op`
+func (op dataOp) mutator() build.TransactionMutator {
+	if op.value == nil {
+		return build.ClearData(op.name)
+	}
+
+	return build.SetData(op.name, op.value)
+}
+
+// chunkName returns the name of the data entry holding chunk `i` of the value
+// at `key`.
+func chunkName(key string, i int) string {
+	return fmt.Sprintf("%s#%d", key, i)
+}
+
+// planSet returns the data operations that set the kv value at `key` to
+// `value`, given the account's `existing` data entries.  Values longer than
+// MaxDataLength, or that could be mistaken for a chunk header, are split
+// across numbered data entries beneath a header at `key`.  The chunks of a
+// previous value that are no longer used are cleared.  Empty values, nil
+// included, are rejected, as stellar would take them to clear the data entry.
+func planSet(
+	key string,
+	value []byte,
+	existing map[string][]byte,
+) ([]dataOp, error) {
+	if key == "" || len(key) > MaxDataLength {
+		return nil, errors.Errorf("stellar: invalid key length %d", len(key))
+	}
+
+	if len(value) == 0 {
+		return nil, errors.New("stellar: cannot set an empty value, use Delete")
+	}
+
+	previous, err := chunkCount(key, existing[key])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(value) <= MaxDataLength && !bytes.HasPrefix(value, []byte(chunkHeader)) {
+		ops := []dataOp{{key, value}}
+		for i := 0; i < previous; i++ {
+			ops = append(ops, dataOp{name: chunkName(key, i)})
+		}
+
+		return ops, nil
+	}
+
+	chunks := (len(value) + MaxDataLength - 1) / MaxDataLength
+	if chunks > MaxValueChunks {
+		return nil, errors.Errorf(
+			"stellar: value of %d bytes exceeds the maximum of %d",
+			len(value), MaxValueChunks*MaxDataLength,
+		)
+	}
+
+	if len(chunkName(key, chunks-1)) > MaxDataLength {
+		return nil, errors.New("stellar: key too long to hold a large value")
+	}
+
+	header := fmt.Sprintf("%s%d:%d", chunkHeader, chunks, len(value))
+	ops := []dataOp{{key, []byte(header)}}
+	for i := 0; i < chunks; i++ {
+		end := (i + 1) * MaxDataLength
+		if end > len(value) {
+			end = len(value)
+		}
+
+		ops = append(ops, dataOp{chunkName(key, i), value[i*MaxDataLength : end]})
+	}
+
+	for i := chunks; i < previous; i++ {
+		ops = append(ops, dataOp{name: chunkName(key, i)})
+	}
+
+	return ops, nil
+}
+
+// planDelete returns the data operations that clear the kv value at `key`,
+// including any chunks it is split across, given the account's `existing`
+// data entries.
+func planDelete(key string, existing map[string][]byte) ([]dataOp, error) {
+	value, ok := existing[key]
+	if !ok {
+		return nil, nil
+	}
+
+	chunks, err := chunkCount(key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := []dataOp{{name: key}}
+	for i := 0; i < chunks; i++ {
+		ops = append(ops, dataOp{name: chunkName(key, i)})
+	}
+
+	return ops, nil
+}
+
+// listKeys returns the sorted kv keys that begin with `prefix` in the
+// account's data entries `data`, omitting the entries that hold chunks.
+func listKeys(data map[string][]byte, prefix string) []string {
+	chunks := map[string]bool{}
+	for key, value := range data {
+		n, err := chunkCount(key, value)
+		if err != nil {
+			continue
+		}
+
+		for i := 0; i < n; i++ {
+			chunks[chunkName(key, i)] = true
+		}
+	}
+
+	var keys []string
+	for key := range data {
+		if !chunks[key] && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// joinValue returns the kv value at `key` from the account's data entries
+// `data`, putting a value split across numbered data entries back together.
+func joinValue(key string, data map[string][]byte) ([]byte, error) {
+	value, ok := data[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if !bytes.HasPrefix(value, []byte(chunkHeader)) {
+		return value, nil
+	}
+
+	chunks, length, err := parseChunkHeader(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret bytes.Buffer
+	for i := 0; i < chunks; i++ {
+		chunk, ok := data[chunkName(key, i)]
+		if !ok {
+			return nil, errors.Errorf("stellar: chunk %d of %s is missing", i, key)
+		}
+
+		ret.Write(chunk)
+	}
+
+	if ret.Len() != length {
+		return nil, errors.Errorf(
+			"stellar: value at %s is %d bytes, expected %d",
+			key, ret.Len(), length,
+		)
+	}
+
+	return ret.Bytes(), nil
+}
+
+// chunkCount returns the number of chunks `value` is split across, zero when
+// it is not a chunk header.
+func chunkCount(key string, value []byte) (int, error) {
+	if !bytes.HasPrefix(value, []byte(chunkHeader)) {
+		return 0, nil
+	}
+
+	chunks, _, err := parseChunkHeader(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "stellar: existing value at %s", key)
+	}
+
+	return chunks, nil
+}
+
+func parseChunkHeader(header []byte) (chunks int, length int, err error) {
+	fields := strings.Split(strings.TrimPrefix(string(header), chunkHeader), ":")
+	if len(fields) != 2 {
+		err = errors.New("stellar: malformed chunk header")
+		return
+	}
+
+	chunks, err = strconv.Atoi(fields[0])
+	if err != nil || chunks < 1 || chunks > MaxValueChunks {
+		err = errors.New("stellar: malformed chunk count")
+		return
+	}
+
+	length, err = strconv.Atoi(fields[1])
+	if err != nil || length < 0 {
+		err = errors.New("stellar: malformed chunk length")
+		return
+	}
+
+	return
+}
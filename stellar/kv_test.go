@@ -0,0 +1,132 @@
+package stellar
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/kvtest"
+	"github.com/stellar/go-stellar-base/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apply applies `ops` to the data entries `data`
+func apply(data map[string][]byte, ops []dataOp) {
+	for _, op := range ops {
+		if op.value == nil {
+			delete(data, op.name)
+			continue
+		}
+
+		data[op.name] = op.value
+	}
+}
+
+func TestPlanSet(t *testing.T) {
+	data := map[string][]byte{}
+	set := func(key string, value []byte) []dataOp {
+		ops, err := planSet(key, value, data)
+		require.NoError(t, err)
+		apply(data, ops)
+
+		joined, err := joinValue(key, data)
+		require.NoError(t, err)
+		assert.Equal(t, value, joined)
+		return ops
+	}
+
+	// small values are written at the key
+	ops := set("foo", []byte("bar"))
+	assert.Equal(t, []dataOp{{"foo", []byte("bar")}}, ops)
+
+	// large values are split across chunks beneath a header
+	large := bytes.Repeat([]byte("x"), 150)
+	ops = set("foo", large)
+	assert.Len(t, ops, 4)
+	assert.Len(t, data, 4)
+	assert.Len(t, data["foo#2"], 22)
+
+	// shrinking a value clears the unused chunks
+	ops = set("foo", large[:100])
+	assert.Equal(t, dataOp{name: "foo#2"}, ops[len(ops)-1])
+	assert.Len(t, data, 3)
+
+	set("foo", []byte("small"))
+	assert.Len(t, data, 1)
+
+	// values that look like a header are chunked too
+	set("foo", []byte(chunkHeader+"1:5"))
+	assert.Len(t, data, 2)
+
+	// other keys are untouched
+	set("bar", []byte("baz"))
+	value, err := joinValue("foo", data)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(chunkHeader+"1:5"), value)
+
+	// missing keys have no value
+	value, err = joinValue("missing", data)
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	// oversized values and keys fail
+	_, err = planSet("foo", make([]byte, MaxDataLength*MaxValueChunks+1), data)
+	assert.Error(t, err)
+	_, err = planSet(string(make([]byte, MaxDataLength+1)), []byte("bar"), data)
+	assert.Error(t, err)
+
+	// empty values fail rather than deleting the key
+	_, err = planSet("bar", nil, data)
+	assert.Error(t, err)
+	_, err = planSet("bar", []byte{}, data)
+	assert.Error(t, err)
+
+	// a missing chunk fails
+	set("foo", large)
+	delete(data, "foo#1")
+	_, err = joinValue("foo", data)
+	assert.Error(t, err)
+}
+
+// accountKV is a kv over the data entries of a single account, using the same
+// encoding as Client.
+type accountKV struct {
+	data map[string][]byte
+}
+
+func (kv *accountKV) Set(identity dapp.Identity, key string, value []byte) (dapp.TX, error) {
+	ops, err := planSet(key, value, kv.data)
+	if err != nil {
+		return dapp.TX(""), err
+	}
+
+	apply(kv.data, ops)
+	return dapp.TX(key), nil
+}
+
+func (kv *accountKV) Get(identity dapp.Identity, key string) ([]byte, error) {
+	return joinValue(key, kv.data)
+}
+
+func (kv *accountKV) Delete(identity dapp.Identity, key string) (dapp.TX, error) {
+	ops, err := planDelete(key, kv.data)
+	if err != nil {
+		return dapp.TX(""), err
+	}
+
+	apply(kv.data, ops)
+	return dapp.TX(key), nil
+}
+
+func (kv *accountKV) List(identity dapp.Identity, prefix string) ([]string, error) {
+	return listKeys(kv.data, prefix), nil
+}
+
+func TestAccountKV(t *testing.T) {
+	kv := &accountKV{data: map[string][]byte{}}
+	kvtest.TestKV(t, kv, &Identity{KP: keypair.MustParse(ClaimIdentity)})
+
+	// chunks are cleared along with their value, and never listed
+	assert.Len(t, kv.data, 1)
+}
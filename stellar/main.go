@@ -1,23 +1,30 @@
 package stellar
 
 import (
+	"context"
 	"fmt"
 	// "log"
 	"encoding/base64"
-	"encoding/json"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/dappstore/go-dapp"
 	"github.com/pkg/errors"
 	"github.com/stellar/go-stellar-base/horizon"
 	"github.com/stellar/go-stellar-base/keypair"
 )
 
-// DefaultClient is the default horizon config
-var DefaultClient = &Client{horizon.DefaultTestNetClient}
+// DefaultClient is the default horizon config, connected to the SDF testnet
+var DefaultClient = New()
 
 // Client connects to the stellar network
 type Client struct {
 	*horizon.Client
+	passphrase string
+	baseFee    uint64
+	timeout    time.Duration
 }
 
 // Identity implements dapp.Identity
@@ -25,23 +32,37 @@ type Identity struct {
 	keypair.KP
 }
 
+// NewURL creates a new client that connects to the horizon server at `url`,
+// signing transactions for the testnet.
+func NewURL(url string) *Client {
+	return New(HorizonURL(url))
+}
+
 // AccountExists returns true if a stellar account at `aid` exists and is
 // funded.
-func AccountExists(h *horizon.Client, aid string) (bool, error) {
+func AccountExists(ctx context.Context, h *horizon.Client, aid string) (bool, error) {
 	url := fmt.Sprintf("%s/accounts/%s", h.URL, aid)
 
-	resp, err := http.Get(url)
+	resp, err := get(ctx, h, url)
 	if err != nil {
 		return false, errors.Wrap(err, "load account data failed")
 	}
-
-	return (resp.StatusCode >= 200 && resp.StatusCode < 300), nil
+	defer discard(resp)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, errors.Wrap(decodeProblem(resp), "load account data failed")
+	}
 }
 
 // FundAccount funds `aid` on the stellar network using the the friendbot at
 // `horizon`.
-func FundAccount(h *horizon.Client, aid string) (string, error) {
-	exists, err := AccountExists(h, aid)
+func FundAccount(ctx context.Context, h *horizon.Client, aid string) (string, error) {
+	exists, err := AccountExists(ctx, h, aid)
 	if err != nil {
 		return "", errors.Wrap(err, "identity existence check errored")
 	}
@@ -58,7 +79,7 @@ func FundAccount(h *horizon.Client, aid string) (string, error) {
 		Hash string `json:"hash"`
 	}
 
-	err = decodeGet(url, &result)
+	err = decodeGet(ctx, h, url, &result)
 	if err != nil {
 		return "", errors.Wrap(err, "fund account: friendbot request failed")
 	}
@@ -68,6 +89,7 @@ func FundAccount(h *horizon.Client, aid string) (string, error) {
 
 // LoadAccountData returns a map of data values on `aid` from `horizon`
 func LoadAccountData(
+	ctx context.Context,
 	h *horizon.Client,
 	aid string,
 ) (ret map[string][]byte, err error) {
@@ -78,9 +100,9 @@ func LoadAccountData(
 		Data map[string]string `json:"data"`
 	}
 
-	err = decodeGet(url, &result)
+	err = decodeGet(ctx, h, url, &result)
 	if err != nil {
-		err = errors.Wrap(err, "load account data: hoirzon request failed")
+		err = errors.Wrap(accountError(err, aid), "load account data: hoirzon request failed")
 		return
 	}
 
@@ -96,22 +118,100 @@ func LoadAccountData(
 	return
 }
 
-func decodeGet(url string, dest interface{}) error {
-	resp, err := http.Get(url)
+// LoadSequence returns the current sequence number of the account `aid`
+func LoadSequence(ctx context.Context, h *horizon.Client, aid string) (uint64, error) {
+	url := fmt.Sprintf("%s/accounts/%s", h.URL, aid)
+
+	var result struct {
+		Sequence string `json:"sequence"`
+	}
+
+	err := decodeGet(ctx, h, url, &result)
 	if err != nil {
-		return errors.Wrap(err, "horizon: request errored")
+		return 0, errors.Wrap(accountError(err, aid), "load sequence: horizon request failed")
 	}
 
-	defer resp.Body.Close()
-	if !(resp.StatusCode >= 200 && resp.StatusCode < 300) {
-		return errors.New("horizon: request failed")
+	seq, err := strconv.ParseUint(result.Sequence, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "load sequence: invalid sequence number")
+	}
+
+	return seq, nil
+}
+
+// SubmitTransaction submits the base64 encoded transaction envelope `txe` to
+// `horizon`, returning the hash of the applied transaction.  A transaction
+// that fails to apply is described by a TxFailedError.
+func SubmitTransaction(ctx context.Context, h *horizon.Client, txe string) (string, error) {
+	resp, err := post(ctx, h, h.URL+"/transactions", url.Values{"tx": {txe}})
+	if err != nil {
+		return "", errors.Wrap(err, "submit transaction: horizon request failed")
 	}
 
-	enc := json.NewDecoder(resp.Body)
-	err = enc.Decode(dest)
+	var result horizon.TransactionSuccess
+	err = decodeResponse(resp, &result)
 	if err != nil {
-		return errors.Wrap(err, "horizon: decode response failed")
+		return "", errors.Wrap(err, "submit transaction: horizon request failed")
 	}
 
-	return nil
+	return result.Hash, nil
+}
+
+// openURI creates a client from a provider uri.  The accepted schemes are:
+//
+//	stellar://host          the test network, through https://host
+//	stellar+https://host    the same as stellar://host
+//	stellar+testnet://host  the same as stellar://host
+//	stellar+pubnet://host   the public network, through https://host
+//	stellar+http://host     the test network, through http://host
+//
+// An empty host selects the SDF horizon server of the network.  The http
+// scheme has no default server, and suits a local standalone network.  The
+// `network` query parameter selects another network by name or passphrase.
+// Custom networks require a host, as in
+// `stellar+http://localhost:8000?network=Standalone+Network+%3B+February+2017`.
+func openURI(u *url.URL) (interface{}, error) {
+	host := u.Host
+	scheme := "https"
+	network := TestNetwork
+
+	switch u.Scheme {
+	case "stellar+http":
+		scheme = "http"
+	case "stellar+pubnet":
+		network = PublicNetwork
+	}
+
+	if name := u.Query().Get("network"); name != "" {
+		network = ParseNetwork(name)
+	}
+
+	opts := []Option{Network(network)}
+
+	if host == "" {
+		if scheme == "http" {
+			return nil, errors.New("stellar: horizon host required")
+		}
+
+		if network == TestNetwork {
+			return DefaultClient, nil
+		}
+
+		if network != PublicNetwork {
+			return nil, errors.New("stellar: horizon host required for custom network")
+		}
+
+		return New(opts...), nil
+	}
+
+	opts = append(opts, HorizonURL(scheme+"://"+host+u.Path))
+	return New(opts...), nil
+}
+
+func init() {
+	dapp.RegisterProvider("stellar", openURI)
+	dapp.RegisterProvider("stellar+testnet", openURI)
+	dapp.RegisterProvider("stellar+pubnet", openURI)
+	dapp.RegisterProvider("stellar+http", openURI)
+	dapp.RegisterProvider("stellar+https", openURI)
 }
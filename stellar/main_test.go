@@ -2,11 +2,18 @@ package stellar_test
 
 import (
 	"github.com/dappstore/go-dapp"
+	"github.com/dappstore/go-dapp/app"
 	"github.com/dappstore/go-dapp/protocols/claim"
+	"github.com/dappstore/go-dapp/session"
 	"github.com/dappstore/go-dapp/stellar"
 )
 
 var _ dapp.Identity = &stellar.Identity{}
+var _ session.Exportable = &stellar.Identity{}
 var _ claim.MakesClaims = stellar.DefaultClient
 var _ dapp.KV = stellar.DefaultClient
+var _ dapp.KVDeleter = stellar.DefaultClient
+var _ dapp.KVLister = stellar.DefaultClient
 var _ dapp.IdentityProvider = stellar.DefaultClient
+var _ app.PaymentProvider = stellar.DefaultClient
+var _ dapp.HealthChecker = stellar.DefaultClient
@@ -0,0 +1,162 @@
+package stellar
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/stellar/go-stellar-base/build"
+	"github.com/stellar/go-stellar-base/horizon"
+)
+
+// The network passphrases of the stellar networks run by the SDF.  A local
+// standalone network uses a passphrase of its own choosing.
+var (
+	PublicNetwork = build.PublicNetwork.Passphrase
+	TestNetwork   = build.TestNetwork.Passphrase
+)
+
+// The urls of the horizon servers run by the SDF
+const (
+	PublicHorizonURL = "https://horizon.stellar.org"
+	TestHorizonURL   = "https://horizon-testnet.stellar.org"
+)
+
+// Option configures a client created by New
+type Option func(*options)
+
+type options struct {
+	url        string
+	passphrase string
+	http       horizon.HTTP
+	userAgent  string
+	baseFee    uint64
+	timeout    time.Duration
+}
+
+// HorizonURL connects the client to the horizon server at `url`.  When not
+// provided, the client connects to the SDF horizon server for its network.
+func HorizonURL(url string) Option {
+	return func(o *options) { o.url = url }
+}
+
+// Network signs the client's transactions for the network identified by
+// `passphrase`, such as PublicNetwork or TestNetwork.  Clients of any other
+// network should also provide a HorizonURL.  The default is TestNetwork.
+func Network(passphrase string) Option {
+	return func(o *options) { o.passphrase = passphrase }
+}
+
+// HTTPClient makes the client's horizon requests using `client` instead of
+// http.DefaultClient.
+func HTTPClient(client horizon.HTTP) Option {
+	return func(o *options) { o.http = client }
+}
+
+// UserAgent sets the User-Agent header of the client's horizon requests
+func UserAgent(agent string) Option {
+	return func(o *options) { o.userAgent = agent }
+}
+
+// BaseFee sets the fee, in stroops, paid for each operation of the client's
+// transactions.  When not provided, the network's minimum fee is paid.
+func BaseFee(stroops uint64) Option {
+	return func(o *options) { o.baseFee = stroops }
+}
+
+// Timeout bounds each kv and identity operation of the client, including the
+// retries of its horizon requests, as the dapp interfaces they implement take
+// no context.  When not provided, these operations do not time out.
+func Timeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// New creates a new client configured by `opts`.  Without options, the client
+// connects to the SDF testnet.
+func New(opts ...Option) *Client {
+	o := options{
+		passphrase: TestNetwork,
+		http:       http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.url == "" {
+		o.url = TestHorizonURL
+		if o.passphrase == PublicNetwork {
+			o.url = PublicHorizonURL
+		}
+	}
+
+	if o.userAgent != "" {
+		o.http = &userAgentHTTP{HTTP: o.http, agent: o.userAgent}
+	}
+
+	return &Client{
+		Client:     &horizon.Client{URL: o.url, Client: o.http},
+		passphrase: o.passphrase,
+		baseFee:    o.baseFee,
+		timeout:    o.timeout,
+	}
+}
+
+// NetworkPassphrase returns the passphrase of the network the client signs
+// transactions for, TestNetwork for a client not created by New.
+func (c *Client) NetworkPassphrase() string {
+	if c.passphrase == "" {
+		return TestNetwork
+	}
+
+	return c.passphrase
+}
+
+// ParseNetwork returns the passphrase of the network named `name`: "public"
+// or "pubnet" for PublicNetwork, "test" or "testnet" for TestNetwork.  Any
+// other name is taken to be the passphrase of a custom network.
+func ParseNetwork(name string) string {
+	switch strings.ToLower(name) {
+	case "public", "pubnet":
+		return PublicNetwork
+	case "test", "testnet":
+		return TestNetwork
+	default:
+		return name
+	}
+}
+
+// userAgentHTTP decorates a horizon.HTTP, setting the User-Agent header of
+// every request.
+type userAgentHTTP struct {
+	horizon.HTTP
+	agent string
+}
+
+func (h *userAgentHTTP) Do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("User-Agent", h.agent)
+	return h.HTTP.Do(req)
+}
+
+func (h *userAgentHTTP) Get(target string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Do(req)
+}
+
+func (h *userAgentHTTP) PostForm(
+	target string,
+	data url.Values,
+) (*http.Response, error) {
+	req, err := http.NewRequest("POST", target, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return h.Do(req)
+}
@@ -0,0 +1,109 @@
+package stellar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	// defaults to the testnet
+	c := New()
+	assert.Equal(t, TestHorizonURL, c.URL)
+	assert.Equal(t, TestNetwork, c.NetworkPassphrase())
+	assert.Equal(t, http.DefaultClient, c.Client.Client)
+
+	// the public network defaults to the public horizon
+	c = New(Network(PublicNetwork))
+	assert.Equal(t, PublicHorizonURL, c.URL)
+	assert.Equal(t, PublicNetwork, c.NetworkPassphrase())
+
+	// custom networks
+	c = New(
+		HorizonURL("http://localhost:8000"),
+		Network("Standalone Network ; February 2017"),
+		BaseFee(200),
+		Timeout(time.Minute),
+	)
+	assert.Equal(t, "http://localhost:8000", c.URL)
+	assert.Equal(t, "Standalone Network ; February 2017", c.NetworkPassphrase())
+	assert.Equal(t, uint64(200), c.baseFee)
+	assert.Equal(t, time.Minute, c.timeout)
+
+	// clients not created by New sign for the testnet
+	assert.Equal(t, TestNetwork, (&Client{}).NetworkPassphrase())
+}
+
+func TestNew_HTTP(t *testing.T) {
+	var agent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent = r.Header.Get("User-Agent")
+		fmt.Fprintf(w, `{"network_passphrase":%q}`, TestNetwork)
+	}))
+	defer srv.Close()
+
+	hc := &http.Client{}
+	c := New(HorizonURL(srv.URL), HTTPClient(hc), UserAgent("coinop/1.0"))
+	require.NoError(t, c.CheckHealth(context.Background()))
+	assert.Equal(t, "coinop/1.0", agent)
+
+	// the user agent decorates the configured client
+	ua, ok := c.Client.Client.(*userAgentHTTP)
+	require.True(t, ok)
+	assert.Equal(t, hc, ua.HTTP)
+}
+
+func TestParseNetwork(t *testing.T) {
+	assert.Equal(t, PublicNetwork, ParseNetwork("public"))
+	assert.Equal(t, PublicNetwork, ParseNetwork("pubnet"))
+	assert.Equal(t, TestNetwork, ParseNetwork("Test"))
+	assert.Equal(t, TestNetwork, ParseNetwork("testnet"))
+	assert.Equal(t, "Custom ; 2017", ParseNetwork("Custom ; 2017"))
+}
+
+func TestOpenURI(t *testing.T) {
+	cases := []struct {
+		uri        string
+		url        string
+		passphrase string
+	}{
+		{"stellar://", TestHorizonURL, TestNetwork},
+		{"stellar+testnet://horizon.example.com", "https://horizon.example.com", TestNetwork},
+		{"stellar+pubnet://", PublicHorizonURL, PublicNetwork},
+		{"stellar+pubnet://horizon.example.com", "https://horizon.example.com", PublicNetwork},
+		{"stellar+http://localhost:8000?network=public", "http://localhost:8000", PublicNetwork},
+		{
+			"stellar+http://localhost:8000?network=Standalone+Network+%3B+February+2017",
+			"http://localhost:8000",
+			"Standalone Network ; February 2017",
+		},
+	}
+
+	for _, kase := range cases {
+		u, err := url.Parse(kase.uri)
+		require.NoError(t, err)
+
+		p, err := openURI(u)
+		if assert.NoError(t, err, kase.uri) {
+			c := p.(*Client)
+			assert.Equal(t, kase.url, c.URL, kase.uri)
+			assert.Equal(t, kase.passphrase, c.NetworkPassphrase(), kase.uri)
+		}
+	}
+
+	// custom networks need a horizon host
+	for _, uri := range []string{"stellar+http://", "stellar://?network=custom"} {
+		u, err := url.Parse(uri)
+		require.NoError(t, err)
+
+		_, err = openURI(u)
+		assert.Error(t, err, uri)
+	}
+}
@@ -0,0 +1,129 @@
+package stellar
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dappstore/go-dapp"
+	"github.com/pkg/errors"
+	"github.com/stellar/go-stellar-base/build"
+)
+
+// PaymentPollInterval is the duration between successive horizon requests
+// made while waiting for a payment.
+var PaymentPollInterval = 5 * time.Second
+
+// Payment represents a single payment operation as reported by horizon
+type Payment struct {
+	ID              string `json:"id"`
+	PagingToken     string `json:"paging_token"`
+	Type            string `json:"type"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	AssetType       string `json:"asset_type"`
+	Amount          string `json:"amount"`
+	TransactionHash string `json:"transaction_hash"`
+}
+
+// SendPayment sends a native payment of `amount` lumens from `from` to `to`.
+func (c *Client) SendPayment(
+	ctx context.Context,
+	from dapp.Identity,
+	to dapp.Identity,
+	amount string,
+) (dapp.TX, error) {
+	sid, ok := from.(*Identity)
+	if !ok {
+		return dapp.TX(""), errors.New("stellar: payment source is not a stellar identity")
+	}
+
+	return c.submit(ctx, sid,
+		build.Payment(
+			build.Destination{AddressOrSeed: to.PublicKey()},
+			build.NativeAmount{Amount: amount},
+		),
+	)
+}
+
+// WaitForPayment blocks until a native payment to `to` is seen by horizon,
+// returning the transaction that included the payment. Only payments made
+// after WaitForPayment is called are considered.
+func (c *Client) WaitForPayment(
+	ctx context.Context,
+	to dapp.Identity,
+) (dapp.TX, error) {
+	aid := to.PublicKey()
+
+	cursor, err := c.latestPaymentCursor(ctx, aid)
+	if err != nil {
+		return dapp.TX(""), errors.Wrap(err, "stellar: failed to load payment cursor")
+	}
+
+	for {
+		var payments []Payment
+		payments, err = c.loadPayments(ctx, aid, cursor)
+		if err != nil {
+			return dapp.TX(""), errors.Wrap(err, "stellar: failed to load payments")
+		}
+
+		for _, p := range payments {
+			cursor = p.PagingToken
+
+			if p.Type == "payment" && p.To == aid && p.AssetType == "native" {
+				return dapp.TX(p.TransactionHash), nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return dapp.TX(""), errors.Wrap(ctx.Err(), "stellar: wait for payment canceled")
+		case <-time.After(PaymentPollInterval):
+		}
+	}
+}
+
+// latestPaymentCursor returns the paging token of the most recent payment
+// involving `aid`, or the empty string if the account has no payments.
+func (c *Client) latestPaymentCursor(ctx context.Context, aid string) (string, error) {
+	url := fmt.Sprintf("%s/accounts/%s/payments?order=desc&limit=1", c.URL, aid)
+
+	var result paymentsPage
+	err := decodeGet(ctx, c.Client, url, &result)
+	if err != nil {
+		return "", errors.Wrap(err, "latest payment: horizon request failed")
+	}
+
+	if len(result.Embedded.Records) == 0 {
+		return "", nil
+	}
+
+	return result.Embedded.Records[0].PagingToken, nil
+}
+
+// loadPayments returns the payments involving `aid` that occurred after
+// `cursor`, in ascending order.
+func (c *Client) loadPayments(
+	ctx context.Context,
+	aid string,
+	cursor string,
+) ([]Payment, error) {
+	url := fmt.Sprintf(
+		"%s/accounts/%s/payments?order=asc&limit=200&cursor=%s",
+		c.URL, aid, cursor,
+	)
+
+	var result paymentsPage
+	err := decodeGet(ctx, c.Client, url, &result)
+	if err != nil {
+		return nil, errors.Wrap(err, "load payments: horizon request failed")
+	}
+
+	return result.Embedded.Records, nil
+}
+
+type paymentsPage struct {
+	Embedded struct {
+		Records []Payment `json:"records"`
+	} `json:"_embedded"`
+}
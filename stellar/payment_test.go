@@ -0,0 +1,122 @@
+package stellar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stellar/go-stellar-base/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SendPayment(t *testing.T) {
+	kp, err := keypair.Random()
+	require.NoError(t, err)
+	from := &Identity{KP: kp}
+	to := &Identity{KP: keypair.MustParse("GDGIXJPUTJIYHHJ2TYWO2HJMFNT7M767ZB33SFGTD77JUE3YZ6YZBUD4")}
+
+	defer func(backoff time.Duration) { RetryBackoff = backoff }(RetryBackoff)
+	RetryBackoff = time.Millisecond
+
+	var submitted string
+	var submissions int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/accounts/"+from.Address():
+			fmt.Fprintf(w, `{"id":"%s","sequence":"100"}`, from.Address())
+		case r.Method == "POST" && r.URL.Path == "/transactions":
+			submissions++
+			if submissions == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			submitted = r.FormValue("tx")
+			fmt.Fprint(w, `{"hash":"abcdef","ledger":1}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewURL(srv.URL)
+
+	// rate limited submissions are retried
+	tx, err := c.SendPayment(context.Background(), from, to, "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", string(tx))
+	assert.Equal(t, 2, submissions)
+	assert.NotEmpty(t, submitted)
+
+	// public-only identities cannot pay
+	_, err = c.SendPayment(context.Background(), to, from, "1.0")
+	assert.Error(t, err)
+
+	// payments are canceled with their context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	submissions = 0
+	_, err = c.SendPayment(ctx, from, to, "1.0")
+	assert.Error(t, err)
+	assert.Equal(t, 0, submissions)
+
+	// unfunded accounts cannot pay
+	unfunded, err := keypair.Random()
+	require.NoError(t, err)
+	_, err = c.SendPayment(context.Background(), &Identity{KP: unfunded}, to, "1.0")
+	assert.True(t, errors.Is(err, ErrAccountNotFound))
+}
+
+func TestClient_WaitForPayment(t *testing.T) {
+	defer func(d time.Duration) { PaymentPollInterval = d }(PaymentPollInterval)
+	PaymentPollInterval = 10 * time.Millisecond
+	to := &Identity{KP: keypair.MustParse("GDGIXJPUTJIYHHJ2TYWO2HJMFNT7M767ZB33SFGTD77JUE3YZ6YZBUD4")}
+	paymentsPath := "/accounts/" + to.Address() + "/payments"
+
+	// polls is shared with the server's goroutines
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != paymentsPath {
+			http.NotFound(w, r)
+			return
+		}
+
+		q := r.URL.Query()
+		switch {
+		case q.Get("order") == "desc":
+			// an old payment, which must be ignored
+			fmt.Fprintf(w, `{"_embedded":{"records":[
+				{"paging_token":"1","type":"payment","to":"%s","asset_type":"native","transaction_hash":"old"}
+			]}}`, to.Address())
+		case atomic.AddInt32(&polls, 1) <= 2:
+			fmt.Fprint(w, `{"_embedded":{"records":[]}}`)
+		default:
+			assert.Equal(t, "1", q.Get("cursor"))
+			fmt.Fprintf(w, `{"_embedded":{"records":[
+				{"paging_token":"2","type":"payment","to":"%s","asset_type":"credit_alphanum4","transaction_hash":"credit"},
+				{"paging_token":"3","type":"payment","to":"%s","asset_type":"native","transaction_hash":"new"}
+			]}}`, to.Address(), to.Address())
+		}
+	}))
+	defer srv.Close()
+
+	c := NewURL(srv.URL)
+
+	tx, err := c.WaitForPayment(context.Background(), to)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(tx))
+
+	// canceled waits return an error
+	atomic.StoreInt32(&polls, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err = c.WaitForPayment(ctx, to)
+	assert.Error(t, err)
+}
@@ -0,0 +1,207 @@
+package stellar
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stellar/go-stellar-base/horizon"
+)
+
+// MaxRetries is the number of times a horizon request is retried after a rate
+// limited or server error response.
+var MaxRetries = 4
+
+// RetryBackoff is the delay before the first retry of a horizon request.  The
+// delay doubles with every subsequent retry, up to MaxRetryBackoff.
+var RetryBackoff = 500 * time.Millisecond
+
+// MaxRetryBackoff is the longest delay between retries of a horizon request,
+// including delays requested by a Retry-After header.
+var MaxRetryBackoff = 30 * time.Second
+
+// get performs a GET request of `url` using the http client of `h`, retrying
+// rate limited and server error responses with exponential backoff.  The
+// caller must close the body of the returned response.
+func get(ctx context.Context, h *horizon.Client, url string) (*http.Response, error) {
+	return do(ctx, h, "GET", url, nil)
+}
+
+// post performs a POST request of `target` with the form `form`.  Unlike get,
+// server errors are not retried: horizon may have applied a transaction
+// before failing to respond, and resubmitting it would fail with tx_bad_seq.
+// Only rate limited responses and failures to connect are retried.  The
+// caller must close the body of the returned response.
+func post(
+	ctx context.Context,
+	h *horizon.Client,
+	target string,
+	form url.Values,
+) (*http.Response, error) {
+	return do(ctx, h, "POST", target, form)
+}
+
+// do performs a `method` request of `target` using the http client of `h`,
+// sending `form` as the body of the request when not nil.  Failures to
+// connect and the responses that shouldRetry allows for `method` are retried
+// with exponential backoff.
+func do(
+	ctx context.Context,
+	h *horizon.Client,
+	method string,
+	target string,
+	form url.Values,
+) (*http.Response, error) {
+	hc := h.Client
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if form != nil {
+			body = strings.NewReader(form.Encode())
+		}
+
+		req, err := http.NewRequest(method, target, body)
+		if err != nil {
+			return nil, errors.Wrap(err, "horizon: failed to create request")
+		}
+
+		if form != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+
+		resp, err := hc.Do(req.WithContext(ctx))
+		switch {
+		case err != nil && (!isDialError(err) || attempt >= MaxRetries):
+			return nil, errors.Wrap(err, "horizon: request errored")
+		case err != nil:
+			resp = nil
+		case !shouldRetry(method, resp.StatusCode) || attempt >= MaxRetries:
+			return resp, nil
+		}
+
+		delay := retryDelay(resp, attempt, time.Now())
+		if resp != nil {
+			discard(resp)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "horizon: request canceled")
+		case <-time.After(delay):
+		}
+	}
+}
+
+// decodeGet performs a GET request of `url` using `h`, decoding the json
+// response into `dest`.
+func decodeGet(
+	ctx context.Context,
+	h *horizon.Client,
+	url string,
+	dest interface{},
+) error {
+	resp, err := get(ctx, h, url)
+	if err != nil {
+		return err
+	}
+
+	return decodeResponse(resp, dest)
+}
+
+// decodeResponse decodes the json body of the horizon response `resp` into
+// `dest`, or the problem it describes when it failed.  The body of `resp` is
+// closed.
+func decodeResponse(resp *http.Response, dest interface{}) error {
+	defer discard(resp)
+
+	if !(resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return decodeProblem(resp)
+	}
+
+	err := json.NewDecoder(resp.Body).Decode(dest)
+	if err != nil {
+		return errors.Wrap(err, "horizon: decode response failed")
+	}
+
+	return nil
+}
+
+// shouldRetry returns true when a response with `status` to a `method`
+// request is worth retrying.  Only GET requests are retried after a server
+// error, as the request may have taken effect.
+func shouldRetry(method string, status int) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+
+	return method == "GET" && status >= 500
+}
+
+// isDialError returns true if `err` is a failure to connect to the server,
+// such that the request was never sent.
+func isDialError(err error) bool {
+	operr, ok := errors.Cause(err).(*net.OpError)
+	if !ok {
+		if uerr, isURL := errors.Cause(err).(*url.Error); isURL {
+			operr, ok = uerr.Err.(*net.OpError)
+		}
+	}
+
+	return ok && operr.Op == "dial"
+}
+
+// retryDelay returns the delay before retrying the request that received
+// `resp` on its `attempt`th attempt, honoring the Retry-After header of the
+// response.  `resp` is nil when the request failed to connect.
+func retryDelay(resp *http.Response, attempt int, now time.Time) time.Duration {
+	delay := RetryBackoff
+	for i := 0; i < attempt && delay < MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > MaxRetryBackoff {
+		delay = MaxRetryBackoff
+	}
+
+	if resp == nil {
+		return delay
+	}
+
+	after := resp.Header.Get("Retry-After")
+	if after == "" {
+		return delay
+	}
+
+	if seconds, err := strconv.Atoi(after); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(after); err == nil {
+		delay = at.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+	}
+
+	if delay > MaxRetryBackoff {
+		delay = MaxRetryBackoff
+	}
+
+	return delay
+}
+
+// discard reads the remainder of the body of `resp` and closes it, such that
+// the underlying connection can be reused.
+func discard(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
@@ -0,0 +1,220 @@
+package stellar
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stellar/go-stellar-base/horizon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet_Retries(t *testing.T) {
+	defer func(backoff time.Duration) { RetryBackoff = backoff }(RetryBackoff)
+	RetryBackoff = time.Millisecond
+
+	var requests int
+	failures := 2
+	retryAfter := "0"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case requests > failures:
+			fmt.Fprint(w, `{"id":"GABC"}`)
+		case requests == 1:
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	h := &horizon.Client{URL: srv.URL, Client: http.DefaultClient}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+
+	// rate limited and server errors are retried
+	require.NoError(t, decodeGet(context.Background(), h, srv.URL, &result))
+	assert.Equal(t, "GABC", result.ID)
+	assert.Equal(t, 3, requests)
+
+	// gives up after MaxRetries
+	requests = 0
+	failures = MaxRetries + 10
+	err := decodeGet(context.Background(), h, srv.URL, &result)
+	assert.Error(t, err)
+	assert.Equal(t, MaxRetries+1, requests)
+
+	// waiting to retry is canceled with the context
+	RetryBackoff = time.Minute
+	retryAfter = ""
+	requests = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = decodeGet(ctx, h, srv.URL, &result)
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+func TestPost_Retries(t *testing.T) {
+	defer func(backoff time.Duration) { RetryBackoff = backoff }(RetryBackoff)
+	RetryBackoff = time.Millisecond
+
+	var forms []string
+	failure := http.StatusTooManyRequests
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		forms = append(forms, r.FormValue("tx"))
+		if len(forms) == 1 && failure != 0 {
+			w.WriteHeader(failure)
+			return
+		}
+
+		fmt.Fprint(w, `{"hash":"abcdef"}`)
+	}))
+	defer srv.Close()
+
+	h := &horizon.Client{URL: srv.URL, Client: http.DefaultClient}
+
+	// rate limited submissions are resent with their form
+	hash, err := SubmitTransaction(context.Background(), h, "AAAA")
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", hash)
+	assert.Equal(t, []string{"AAAA", "AAAA"}, forms)
+
+	// server errors are not, as the transaction may have been applied
+	forms = nil
+	failure = http.StatusGatewayTimeout
+	_, err = SubmitTransaction(context.Background(), h, "AAAA")
+	assert.Error(t, err)
+	assert.Len(t, forms, 1)
+
+	// failures to connect are, as the transaction was never sent
+	forms = nil
+	failure = 0
+	dials := 0
+	h.Client = &http.Client{Transport: roundTripper(func(r *http.Request) (*http.Response, error) {
+		dials++
+		if dials == 1 {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("refused")}
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	hash, err = SubmitTransaction(context.Background(), h, "AAAA")
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", hash)
+	assert.Equal(t, 2, dials)
+}
+
+func TestLoadSequence(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/accounts/GABC" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprint(w, `{"id":"GABC","sequence":"18446744073709551615"}`)
+	}))
+	defer srv.Close()
+
+	h := &horizon.Client{URL: srv.URL, Client: http.DefaultClient}
+
+	seq, err := LoadSequence(context.Background(), h, "GABC")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(18446744073709551615), seq)
+
+	_, err = LoadSequence(context.Background(), h, "GDEF")
+	assert.True(t, errors.Is(err, ErrAccountNotFound))
+}
+
+func TestGet_HTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{}}`)
+	}))
+	defer srv.Close()
+
+	var used bool
+	hc := &http.Client{Transport: roundTripper(func(r *http.Request) (*http.Response, error) {
+		used = true
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	c := New(HorizonURL(srv.URL), HTTPClient(hc))
+	_, err := LoadAccountData(context.Background(), c.Client, "GABC")
+	require.NoError(t, err)
+	assert.True(t, used)
+}
+
+func TestAccountExists(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+
+	h := &horizon.Client{URL: srv.URL, Client: http.DefaultClient}
+
+	exists, err := AccountExists(context.Background(), h, "GABC")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	status = http.StatusNotFound
+	exists, err = AccountExists(context.Background(), h, "GABC")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// other failures are errors, not missing accounts
+	status = http.StatusBadRequest
+	_, err = AccountExists(context.Background(), h, "GABC")
+	assert.Error(t, err)
+}
+
+func TestRetryDelay(t *testing.T) {
+	defer func(backoff, max time.Duration) {
+		RetryBackoff, MaxRetryBackoff = backoff, max
+	}(RetryBackoff, MaxRetryBackoff)
+	RetryBackoff = time.Second
+	MaxRetryBackoff = 10 * time.Second
+
+	now := time.Date(2017, 2, 1, 12, 0, 0, 0, time.UTC)
+	resp := func(after string) *http.Response {
+		r := &http.Response{Header: http.Header{}}
+		if after != "" {
+			r.Header.Set("Retry-After", after)
+		}
+		return r
+	}
+
+	// exponential backoff
+	assert.Equal(t, time.Second, retryDelay(resp(""), 0, now))
+	assert.Equal(t, 4*time.Second, retryDelay(resp(""), 2, now))
+	assert.Equal(t, 10*time.Second, retryDelay(resp(""), 5, now))
+	assert.Equal(t, 10*time.Second, retryDelay(resp(""), 80, now))
+
+	// Retry-After, in seconds or as a date
+	assert.Equal(t, 3*time.Second, retryDelay(resp("3"), 0, now))
+	assert.Equal(t, 5*time.Second,
+		retryDelay(resp(now.Add(5*time.Second).Format(http.TimeFormat)), 0, now))
+	assert.Equal(t, time.Duration(0),
+		retryDelay(resp(now.Add(-time.Hour).Format(http.TimeFormat)), 0, now))
+	assert.Equal(t, 10*time.Second, retryDelay(resp("3600"), 0, now))
+
+	// unparseable values fall back to the backoff
+	assert.Equal(t, time.Second, retryDelay(resp("soon"), 0, now))
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (fn roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return fn(r)
+}
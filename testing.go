@@ -1,16 +1,41 @@
 package dapp
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
 	"github.com/pkg/errors"
 )
 
 // ensure our mocks implement our interfaces
 var _ Identity = &MockIdentity{}
+var _ IdentityProvider = &MockIdentityProvider{}
 
 //MockIdentity is a mock identity.  use it in your tests that are dependent upon
 //this package.
 type MockIdentity struct {
 	PK string
+
+	key ed25519.PrivateKey
+}
+
+// NewMockIdentity creates a mock identity whose ed25519 key is derived from
+// `seed`, such that the same seed always produces the same identity.  The
+// identity's PK is the hex encoded public key.
+func NewMockIdentity(seed string) *MockIdentity {
+	raw := sha256.Sum256([]byte(seed))
+	return newMockIdentity(raw[:])
+}
+
+func newMockIdentity(seed []byte) *MockIdentity {
+	key := ed25519.NewKeyFromSeed(seed)
+	return &MockIdentity{
+		PK:  hex.EncodeToString(key.Public().(ed25519.PublicKey)),
+		key: key,
+	}
 }
 
 // Equals implements dapp.Identity
@@ -30,10 +55,76 @@ func (i *MockIdentity) PublicKey() string {
 
 // Verify implement `Identity`
 func (i *MockIdentity) Verify(input []byte, signature []byte) error {
-	return errors.New("mock identity cannot verify signatures")
+	pub, err := hex.DecodeString(i.PK)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return errors.New("mock identity has no public key to verify signatures")
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(pub), input, signature) {
+		return errors.New("mock identity: invalid signature")
+	}
+
+	return nil
 }
 
 // Sign implement `Identity`
 func (i *MockIdentity) Sign(input []byte) ([]byte, error) {
-	return nil, errors.New("mock identity cannot sign messages")
+	if i.key == nil {
+		return nil, errors.New("mock identity cannot sign messages without a secret key")
+	}
+
+	return ed25519.Sign(i.key, input), nil
+}
+
+// MockIdentityProvider is a mock identity provider that produces
+// MockIdentity values and tracks announcements in memory.
+type MockIdentityProvider struct {
+	lock      sync.Mutex
+	announced map[string]bool
+}
+
+// ParseIdentity implements `IdentityProvider`.  The returned identity can
+// verify signatures when `str` is the PK of a seeded identity, but can never
+// sign.
+func (p *MockIdentityProvider) ParseIdentity(str string) (Identity, error) {
+	if str == "" {
+		return nil, errors.New("mock identity provider: empty identity")
+	}
+
+	return &MockIdentity{PK: str}, nil
+}
+
+// RandomIdentity implements `IdentityProvider`
+func (p *MockIdentityProvider) RandomIdentity() (Identity, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	_, err := rand.Read(seed)
+	if err != nil {
+		return nil, errors.Wrap(err, "mock identity provider: failed to read random seed")
+	}
+
+	return newMockIdentity(seed), nil
+}
+
+// AnnounceIdentity implements `IdentityProvider`
+func (p *MockIdentityProvider) AnnounceIdentity(id Identity) (TX, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.announced == nil {
+		p.announced = map[string]bool{}
+	}
+
+	if p.announced[id.PublicKey()] {
+		return TX(""), errors.New("mock identity provider: identity already announced")
+	}
+
+	p.announced[id.PublicKey()] = true
+	return TX("announce-" + id.PublicKey()), nil
+}
+
+// IsIdentityAnnounced implements `IdentityProvider`
+func (p *MockIdentityProvider) IsIdentityAnnounced(id Identity) (bool, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.announced[id.PublicKey()], nil
 }
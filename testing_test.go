@@ -0,0 +1,62 @@
+package dapp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockIdentity(t *testing.T) {
+	alice := NewMockIdentity("alice")
+
+	// seeding is deterministic
+	assert.True(t, alice.Equals(NewMockIdentity("alice")))
+	assert.False(t, alice.Equals(NewMockIdentity("bob")))
+
+	sig, err := alice.Sign([]byte("hello"))
+	require.NoError(t, err)
+	assert.NoError(t, alice.Verify([]byte("hello"), sig))
+	assert.Error(t, alice.Verify([]byte("goodbye"), sig))
+	assert.Error(t, NewMockIdentity("bob").Verify([]byte("hello"), sig))
+
+	// public-only identities can verify but not sign
+	public := &MockIdentity{PK: alice.PK}
+	assert.NoError(t, public.Verify([]byte("hello"), sig))
+	_, err = public.Sign([]byte("hello"))
+	assert.Error(t, err)
+
+	// unseeded mocks can do neither
+	plain := &MockIdentity{PK: "GSDSED"}
+	assert.Error(t, plain.Verify([]byte("hello"), sig))
+	_, err = plain.Sign([]byte("hello"))
+	assert.Error(t, err)
+}
+
+func TestMockIdentityProvider(t *testing.T) {
+	p := &MockIdentityProvider{}
+
+	id, err := p.RandomIdentity()
+	require.NoError(t, err)
+
+	parsed, err := p.ParseIdentity(id.PublicKey())
+	require.NoError(t, err)
+	assert.True(t, id.Equals(parsed))
+
+	_, err = p.ParseIdentity("")
+	assert.Error(t, err)
+
+	announced, err := p.IsIdentityAnnounced(id)
+	require.NoError(t, err)
+	assert.False(t, announced)
+
+	_, err = p.AnnounceIdentity(id)
+	require.NoError(t, err)
+
+	announced, err = p.IsIdentityAnnounced(parsed)
+	require.NoError(t, err)
+	assert.True(t, announced)
+
+	_, err = p.AnnounceIdentity(parsed)
+	assert.Error(t, err)
+}